@@ -0,0 +1,40 @@
+// Command migrate-games moves the games stored in MongoDB with one document per user, holding
+// every game of the user, to one document per game, and creates the indexes of the collection.
+// It reads the MongoDB settings from the same configuration as the server and can be run
+// again if it's interrupted
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mercadolibre/minesweeper/config"
+	"github.com/mercadolibre/minesweeper/dao"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("invalid configuration: " + err.Error())
+	}
+	if cfg.Storage.Backend != config.StorageMongoDB {
+		log.Fatal("the storage backend must be " + config.StorageMongoDB)
+	}
+
+	container, err := dao.ConnectContainer(cfg.Storage.MongoURI, cfg.Storage.Database, cfg.Storage.Collection,
+		time.Duration(cfg.Storage.TimeoutSeconds)*time.Second)
+	if err != nil {
+		log.Fatal("connecting to MongoDB: " + err.Error())
+	}
+
+	migrated, err := dao.MigrateUserDocuments(container)
+	if err != nil {
+		log.Fatalf("migrated %d users before failing: %v", migrated, err)
+	}
+	err = container.EnsureIndexes()
+	if err != nil {
+		log.Fatal("creating the indexes: " + err.Error())
+	}
+	fmt.Printf("Migrated the games of %d users\n", migrated)
+}
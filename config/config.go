@@ -0,0 +1,275 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strconv"
+
+	"github.com/mercadolibre/minesweeper/domain"
+	"github.com/mercadolibre/minesweeper/services"
+)
+
+// Storage backends
+const (
+	StorageMemory  = "memory"
+	StorageMongoDB = "mongodb"
+	StorageSQLite  = "sqlite"
+	StorageFile    = "file"
+)
+
+// Log levels, from the most to the least verbose
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+)
+
+const redacted = "REDACTED"
+
+// Config is the configuration of the server. It's read from the JSON file named by
+// MINESWEEPER_CONFIG_FILE, if any, and the environment variables override the file
+type Config struct {
+	Port       string           `json:"port"`
+	LogLevel   string           `json:"log_level"`
+	AdminToken string           `json:"admin_token"`
+	RankedUndo bool             `json:"ranked_undo"`
+	Presets    []*domain.Preset `json:"presets"`
+	Server     Server           `json:"server"`
+	Storage    Storage          `json:"storage"`
+	Limits     Limits           `json:"limits"`
+}
+
+// Server holds the timeouts of the HTTP server
+type Server struct {
+	ReadTimeoutSeconds  int `json:"read_timeout_seconds"`
+	WriteTimeoutSeconds int `json:"write_timeout_seconds"`
+}
+
+// Storage selects where the games are stored, the settings of a backend are only used by it
+type Storage struct {
+	Backend        string `json:"backend"`
+	SQLitePath     string `json:"sqlite_path"`
+	FileDir        string `json:"file_dir"`
+	SnapshotEvery  int    `json:"snapshot_every"`
+	MongoURI       string `json:"mongo_uri"`
+	Database       string `json:"database"`
+	Collection     string `json:"collection"`
+	TimeoutSeconds int    `json:"timeout_seconds"`
+}
+
+// Limits bound the games that can be created
+type Limits struct {
+	MaxRows             int `json:"max_rows"`
+	MaxColumns          int `json:"max_columns"`
+	MaxLayers           int `json:"max_layers"`
+	MaxLives            int `json:"max_lives"`
+	MaxTimeLimitSeconds int `json:"max_time_limit_seconds"`
+}
+
+// Default returns the configuration used for every setting that is not set
+func Default() *Config {
+	return &Config{
+		Port:     "5000",
+		LogLevel: LogLevelInfo,
+		Server: Server{
+			ReadTimeoutSeconds:  10,
+			WriteTimeoutSeconds: 10,
+		},
+		Storage: Storage{
+			Backend:        StorageMemory,
+			SQLitePath:     "minesweeper.db",
+			FileDir:        "data",
+			SnapshotEvery:  1000,
+			Database:       "minesweper",
+			Collection:     "games",
+			TimeoutSeconds: 10,
+		},
+		Limits: Limits{
+			MaxRows:             30,
+			MaxColumns:          30,
+			MaxLayers:           10,
+			MaxLives:            10,
+			MaxTimeLimitSeconds: 86400,
+		},
+	}
+}
+
+// Load reads the configuration from the environment and the optional config file, and validates it
+func Load() (*Config, error) {
+	return load(os.Getenv)
+}
+
+func load(getenv func(string) string) (*Config, error) {
+
+	config := Default()
+	if path := getenv("MINESWEEPER_CONFIG_FILE"); path != "" {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading the config file: %v", err)
+		}
+		err = json.Unmarshal(content, config)
+		if err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+		}
+	}
+
+	err := config.applyEnv(getenv)
+	if err != nil {
+		return nil, err
+	}
+	err = config.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// applyEnv overrides the settings whose environment variable is set
+func (c *Config) applyEnv(getenv func(string) string) error {
+
+	stringSettings := map[string]*string{
+		"PORT":                         &c.Port,
+		"MINESWEEPER_LOG_LEVEL":        &c.LogLevel,
+		"MINESWEEPER_ADMIN_TOKEN":      &c.AdminToken,
+		"MINESWEEPER_STORAGE":          &c.Storage.Backend,
+		"MINESWEEPER_SQLITE_PATH":      &c.Storage.SQLitePath,
+		"MINESWEEPER_FILE_DIR":         &c.Storage.FileDir,
+		"MINESWEEPER_MONGO_URI":        &c.Storage.MongoURI,
+		"MINESWEEPER_MONGO_DATABASE":   &c.Storage.Database,
+		"MINESWEEPER_MONGO_COLLECTION": &c.Storage.Collection,
+	}
+	for name, setting := range stringSettings {
+		if value := getenv(name); value != "" {
+			*setting = value
+		}
+	}
+
+	intSettings := map[string]*int{
+		"MINESWEEPER_READ_TIMEOUT_SECONDS":   &c.Server.ReadTimeoutSeconds,
+		"MINESWEEPER_WRITE_TIMEOUT_SECONDS":  &c.Server.WriteTimeoutSeconds,
+		"MINESWEEPER_DB_TIMEOUT_SECONDS":     &c.Storage.TimeoutSeconds,
+		"MINESWEEPER_SNAPSHOT_EVERY":         &c.Storage.SnapshotEvery,
+		"MINESWEEPER_MAX_ROWS":               &c.Limits.MaxRows,
+		"MINESWEEPER_MAX_COLUMNS":            &c.Limits.MaxColumns,
+		"MINESWEEPER_MAX_LAYERS":             &c.Limits.MaxLayers,
+		"MINESWEEPER_MAX_LIVES":              &c.Limits.MaxLives,
+		"MINESWEEPER_MAX_TIME_LIMIT_SECONDS": &c.Limits.MaxTimeLimitSeconds,
+	}
+	for name, setting := range intSettings {
+		value := getenv(name)
+		if value == "" {
+			continue
+		}
+		intValue, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %s", name, value)
+		}
+		*setting = intValue
+	}
+
+	if value := getenv("MINESWEEPER_RANKED_UNDO"); value != "" {
+		rankedUndo, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid MINESWEEPER_RANKED_UNDO: %s", value)
+		}
+		c.RankedUndo = rankedUndo
+	}
+
+	// A JSON list like [{"name": "tiny", "rows": 5, "columns": 5, "mines": 3}]
+	if value := getenv("MINESWEEPER_PRESETS"); value != "" {
+		presets := make([]*domain.Preset, 0)
+		err := json.Unmarshal([]byte(value), &presets)
+		if err != nil {
+			return fmt.Errorf("invalid MINESWEEPER_PRESETS: %v", err)
+		}
+		c.Presets = presets
+	}
+	return nil
+}
+
+// Validate checks that every setting holds a usable value
+func (c *Config) Validate() error {
+
+	port, err := strconv.Atoi(c.Port)
+	if err != nil || port <= 0 || port > 65535 {
+		return fmt.Errorf("invalid port: %s", c.Port)
+	}
+
+	switch c.LogLevel {
+	case LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError:
+	default:
+		return fmt.Errorf("log level must be %s, %s, %s or %s", LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError)
+	}
+
+	if c.Server.ReadTimeoutSeconds <= 0 || c.Server.WriteTimeoutSeconds <= 0 {
+		return fmt.Errorf("server timeouts must be greater than 0")
+	}
+
+	switch c.Storage.Backend {
+	case StorageMemory:
+	case StorageMongoDB:
+		if c.Storage.MongoURI == "" {
+			return fmt.Errorf("the %s storage needs a mongo uri", StorageMongoDB)
+		}
+		if c.Storage.Database == "" || c.Storage.Collection == "" {
+			return fmt.Errorf("the %s storage needs a database and a collection", StorageMongoDB)
+		}
+		if c.Storage.TimeoutSeconds <= 0 {
+			return fmt.Errorf("storage timeout must be greater than 0")
+		}
+	case StorageSQLite:
+		if c.Storage.SQLitePath == "" {
+			return fmt.Errorf("the %s storage needs a sqlite path", StorageSQLite)
+		}
+	case StorageFile:
+		if c.Storage.FileDir == "" {
+			return fmt.Errorf("the %s storage needs a directory", StorageFile)
+		}
+		if c.Storage.SnapshotEvery <= 0 {
+			return fmt.Errorf("snapshot every must be greater than 0")
+		}
+	default:
+		return fmt.Errorf("storage backend must be %s, %s, %s or %s", StorageMemory, StorageMongoDB, StorageSQLite, StorageFile)
+	}
+
+	if c.Limits.MaxRows <= 0 || c.Limits.MaxColumns <= 0 || c.Limits.MaxLayers <= 0 ||
+		c.Limits.MaxLives <= 0 || c.Limits.MaxTimeLimitSeconds <= 0 {
+		return fmt.Errorf("limits must be greater than 0")
+	}
+
+	err = services.ValidateCustomPresets(c.Presets, c.Limits.MaxRows, c.Limits.MaxColumns)
+	if err != nil {
+		return fmt.Errorf("invalid presets: %v", err)
+	}
+	return nil
+}
+
+// Redacted returns a copy of the configuration without its secrets, that can be shown to operators
+func (c *Config) Redacted() *Config {
+
+	copied := *c
+	if copied.AdminToken != "" {
+		copied.AdminToken = redacted
+	}
+	if copied.Storage.MongoURI != "" {
+		copied.Storage.MongoURI = redactURI(copied.Storage.MongoURI)
+	}
+	return &copied
+}
+
+// redactURI hides the password of the URI, the whole URI if it can't be parsed
+func redactURI(uri string) string {
+
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return redacted
+	}
+	if _, hasPassword := parsed.User.Password(); hasPassword {
+		parsed.User = url.UserPassword(parsed.User.Username(), redacted)
+	}
+	return parsed.String()
+}
@@ -1,12 +1,28 @@
 package constants
 
+// GameStatusCreated tells that no move was made in the game yet
+const GameStatusCreated string = "created"
+
 // GameStatusOnGoing tells that a game is still in process
 const GameStatusOnGoing string = "on_going"
 
+// GameStatusPaused game paused by the player, its time doesn't run
+const GameStatusPaused string = "paused"
+
+// GameStatusTimedOut game whose time limit ran out before it was finished
+const GameStatusTimedOut string = "timed_out"
+
 // GameResultWon tells that a game is won
 const GameResultWon string = "won"
 
-// GameStatusLose tells that a game is lost
+// GameStatusLost tells that a game is lost
+const GameStatusLost string = "lost"
+
+// GameStatusAbandoned tells that the player gave up the game
+const GameStatusAbandoned string = "abandoned"
+
+// GameStatusLose is the status lost games were stored with before GameStatusLost,
+// games are migrated to the new status when they are read
 const GameStatusLose string = "lose"
 
 // FlagRedFlag flags a cell with a red flag
@@ -14,3 +30,54 @@ const FlagRedFlag string = "red_flag"
 
 // FlagQuestionMark flags a cell with a quieston mark
 const FlagQuestionMark string = "question_mark"
+
+// FirstClickSafeCell guarantees that the first revealed cell has no mine
+const FirstClickSafeCell string = "safe_cell"
+
+// FirstClickOpening guarantees that the first revealed cell and its neighbours have no mines
+const FirstClickOpening string = "opening"
+
+// PresetBeginner is the 9x9 board with 10 mines
+const PresetBeginner string = "beginner"
+
+// PresetIntermediate is the 16x16 board with 40 mines
+const PresetIntermediate string = "intermediate"
+
+// PresetExpert is the board of 16 rows and 30 columns with 99 mines
+const PresetExpert string = "expert"
+
+// TopologySquare is the classic grid where every cell has 8 neighbours
+const TopologySquare string = "square"
+
+// TopologyHex is the hexagonal grid where every cell has 6 neighbours
+const TopologyHex string = "hex"
+
+// Topology3D stacks square layers, every cell has 26 neighbours
+const Topology3D string = "3d"
+
+// MoveReveal is a move that revealed a cell
+const MoveReveal string = "reveal"
+
+// MoveFlag is a move that flagged or unflagged a cell
+const MoveFlag string = "flag"
+
+// MoveChord is a move that chorded a revealed number
+const MoveChord string = "chord"
+
+// MoveUndo is a move that rolled back the last move not yet undone
+const MoveUndo string = "undo"
+
+// SortByStartTime sorts the listed games by the time they were created
+const SortByStartTime string = "start_time"
+
+// SortByFinishTime sorts the listed games by the time they were finished
+const SortByFinishTime string = "finish_time"
+
+// SortByMines sorts the listed games by their number of mines
+const SortByMines string = "mines"
+
+// DefaultPageSize is the number of games listed per page when no page size is requested
+const DefaultPageSize int = 20
+
+// MaxPageSize is the largest page of games that can be requested
+const MaxPageSize int = 100
@@ -0,0 +1,19 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mercadolibre/minesweeper/config"
+)
+
+// ConfigController shows the configuration the server is running with
+type ConfigController struct {
+	Config *config.Config
+}
+
+// GetConfig returns the configuration without its secrets
+func (cc ConfigController) GetConfig(c *gin.Context) error {
+	c.JSON(http.StatusOK, cc.Config.Redacted())
+	return nil
+}
@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mercadolibre/minesweeper/config"
 	"github.com/mercadolibre/minesweeper/constants"
 	"github.com/mercadolibre/minesweeper/domain"
 	"github.com/mercadolibre/minesweeper/errors"
@@ -13,20 +16,33 @@ import (
 
 // GameService ...
 type GameService interface {
-	GetGamesByUserID(userID string) (*domain.UserGame, error)
+	ListUserGames(query domain.GameQuery) (*domain.GamePage, error)
 	GetGameByGameID(userID string, gameID int64) (*domain.Game, error)
 	CreateGame(gameRequest *domain.NewGameConditionsRequest) (*domain.Game, error)
 	ShowSolution(userID string, gameID int64) (string, error)
 	FlagCell(flagRequest *domain.FlagCellRequest) (*domain.Game, error)
 	ShowStatus(userID string, gameID int64) (string, error)
 	RevealCell(revealCellRequest *domain.RevealCellRequest) (*domain.Game, error)
+	ChordCell(chordCellRequest *domain.RevealCellRequest) (*domain.Game, error)
+	UndoMove(userID string, gameID int64) (*domain.Game, error)
+	PauseGame(userID string, gameID int64) (*domain.Game, error)
+	ResumeGame(userID string, gameID int64) (*domain.Game, error)
+	AbandonGame(userID string, gameID int64) (*domain.Game, error)
+	GetUserStats(userID string) (*domain.UserStats, error)
+	GetReplay(userID string, gameID int64, move *int) (*domain.GameReplay, error)
 	DeleteAllGames() error
-	GetAllGames() ([]*domain.UserGame, error)
+	DeleteGame(userID string, gameID int64) (bool, error)
+	DeleteUserGames(userID string) (bool, error)
+	ListAllGames(query domain.GameQuery) (*domain.GamePage, error)
+	GetPresets() []*domain.Preset
+	GetPreset(name string) *domain.Preset
+	Now() time.Time
 }
 
 // GameController expone los servicios del controller
 type GameController struct {
 	GameService GameService
+	Limits      config.Limits
 }
 
 // Pong allows validation that the API is responding
@@ -35,14 +51,15 @@ func (gc GameController) Pong(c *gin.Context) {
 	c.JSON(http.StatusOK, "Pong from: minesweeper")
 }
 
-// GetGamesByUserID ...
+// GetGamesByUserID lists a page of the games of a user
 func (gc GameController) GetGamesByUserID(c *gin.Context) error {
 
-	userID, ok := c.Get("userID")
+	query, ok := c.Get("gameQuery")
 	if !ok {
-		return &errors.ApiError{"undefined userID", "internal_server_error", http.StatusInternalServerError}
+		return &errors.ApiError{Message: "undefined gameQuery", ErrorStr: "internal_server_error", Status: http.StatusInternalServerError}
 	}
-	games, err := gc.GameService.GetGamesByUserID(fmt.Sprintf("%v", userID))
+	gameQuery := query.(domain.GameQuery)
+	games, err := gc.GameService.ListUserGames(gameQuery)
 	if err != nil {
 		return &errors.ApiError{
 			Message:  err.Error(),
@@ -58,7 +75,7 @@ func (gc GameController) GetGamesByUserID(c *gin.Context) error {
 			Status:   http.StatusNotFound,
 		})
 	} else {
-		c.JSON(http.StatusOK, games)
+		c.JSON(http.StatusOK, games.PlayerView(gameQuery.UserID, gc.GameService.Now()))
 	}
 	return nil
 }
@@ -68,12 +85,12 @@ func (gc GameController) GetGameByGameID(c *gin.Context) error {
 
 	userID, ok := c.Get("userID")
 	if !ok {
-		return &errors.ApiError{"undefined userID", "internal_server_error", http.StatusInternalServerError}
+		return &errors.ApiError{Message: "undefined userID", ErrorStr: "internal_server_error", Status: http.StatusInternalServerError}
 	}
 
 	gameID, ok := c.Get("gameID")
 	if !ok {
-		return &errors.ApiError{"undefined userID", "internal_server_error", http.StatusInternalServerError}
+		return &errors.ApiError{Message: "undefined userID", ErrorStr: "internal_server_error", Status: http.StatusInternalServerError}
 	}
 
 	game, err := gc.GameService.GetGameByGameID((fmt.Sprintf("%v", userID)), gameID.(int64))
@@ -92,8 +109,44 @@ func (gc GameController) GetGameByGameID(c *gin.Context) error {
 			Status:   http.StatusNotFound,
 		})
 	} else {
-		c.JSON(http.StatusOK, game)
+		c.JSON(http.StatusOK, game.PlayerView(gc.GameService.Now()))
+	}
+	return nil
+}
+
+// GetFullGameByGameID returns a game including the position of every mine,
+// it must only be exposed through privileged routes
+func (gc GameController) GetFullGameByGameID(c *gin.Context) error {
+
+	userID, ok := c.Get("userID")
+	if !ok {
+		return &errors.ApiError{Message: "undefined userID", ErrorStr: "internal_server_error", Status: http.StatusInternalServerError}
+	}
+
+	gameID, ok := c.Get("gameID")
+	if !ok {
+		return &errors.ApiError{Message: "undefined gameID", ErrorStr: "internal_server_error", Status: http.StatusInternalServerError}
+	}
+
+	game, err := gc.GameService.GetGameByGameID(fmt.Sprintf("%v", userID), gameID.(int64))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, &errors.ApiError{
+			Message:  err.Error(),
+			ErrorStr: "internal_server_errror",
+			Status:   http.StatusInternalServerError,
+		})
+		return nil
+	}
+
+	if game == nil {
+		c.JSON(http.StatusNotFound, &errors.ApiError{
+			Message:  "game not found",
+			ErrorStr: "not_found",
+			Status:   http.StatusNotFound,
+		})
+		return nil
 	}
+	c.JSON(http.StatusOK, game)
 	return nil
 }
 
@@ -102,7 +155,7 @@ func (gc GameController) CreateNewGame(c *gin.Context) error {
 
 	boundBody, ok := c.Get("boundBody")
 	if !ok {
-		return &errors.ApiError{"undefined boundBody", "internal_server_errror", http.StatusInternalServerError}
+		return &errors.ApiError{Message: "undefined boundBody", ErrorStr: "internal_server_errror", Status: http.StatusInternalServerError}
 	}
 
 	body := boundBody.(*domain.NewGameConditionsRequest)
@@ -115,7 +168,7 @@ func (gc GameController) CreateNewGame(c *gin.Context) error {
 		})
 		return nil
 	}
-	c.JSON(http.StatusCreated, game)
+	c.JSON(http.StatusCreated, game.PlayerView(gc.GameService.Now()))
 	return nil
 }
 
@@ -124,12 +177,12 @@ func (gc GameController) ShowSolution(c *gin.Context) error {
 
 	userID, ok := c.Get("userID")
 	if !ok {
-		return &errors.ApiError{"undefined userID", "internal_server_error", http.StatusInternalServerError}
+		return &errors.ApiError{Message: "undefined userID", ErrorStr: "internal_server_error", Status: http.StatusInternalServerError}
 	}
 
 	gameID, ok := c.Get("gameID")
 	if !ok {
-		return &errors.ApiError{"undefined userID", "internal_server_error", http.StatusInternalServerError}
+		return &errors.ApiError{Message: "undefined userID", ErrorStr: "internal_server_error", Status: http.StatusInternalServerError}
 	}
 
 	solution, err := gc.GameService.ShowSolution((fmt.Sprintf("%v", userID)), gameID.(int64))
@@ -158,12 +211,12 @@ func (gc GameController) ShowStatus(c *gin.Context) error {
 
 	userID, ok := c.Get("userID")
 	if !ok {
-		return &errors.ApiError{"undefined userID", "internal_server_error", http.StatusInternalServerError}
+		return &errors.ApiError{Message: "undefined userID", ErrorStr: "internal_server_error", Status: http.StatusInternalServerError}
 	}
 
 	gameID, ok := c.Get("gameID")
 	if !ok {
-		return &errors.ApiError{"undefined userID", "internal_server_error", http.StatusInternalServerError}
+		return &errors.ApiError{Message: "undefined userID", ErrorStr: "internal_server_error", Status: http.StatusInternalServerError}
 	}
 
 	solution, err := gc.GameService.ShowStatus((fmt.Sprintf("%v", userID)), gameID.(int64))
@@ -192,7 +245,7 @@ func (gc GameController) FlagCell(c *gin.Context) error {
 
 	boundBody, ok := c.Get("boundBody")
 	if !ok {
-		return &errors.ApiError{"undefined boundBody", "internal_server_errror", http.StatusInternalServerError}
+		return &errors.ApiError{Message: "undefined boundBody", ErrorStr: "internal_server_errror", Status: http.StatusInternalServerError}
 	}
 
 	body := boundBody.(*domain.FlagCellRequest)
@@ -206,7 +259,7 @@ func (gc GameController) FlagCell(c *gin.Context) error {
 		c.JSON(http.StatusBadRequest, game)
 		return nil
 	}
-	c.JSON(http.StatusOK, game)
+	c.JSON(http.StatusOK, game.PlayerView(gc.GameService.Now()))
 	return nil
 }
 
@@ -215,7 +268,7 @@ func (gc GameController) RevealCell(c *gin.Context) error {
 
 	boundBody, ok := c.Get("boundBody")
 	if !ok {
-		return &errors.ApiError{"undefined boundBody", "internal_server_errror", http.StatusInternalServerError}
+		return &errors.ApiError{Message: "undefined boundBody", ErrorStr: "internal_server_errror", Status: http.StatusInternalServerError}
 	}
 
 	body := boundBody.(*domain.RevealCellRequest)
@@ -234,7 +287,252 @@ func (gc GameController) RevealCell(c *gin.Context) error {
 		return nil
 	}
 
-	c.JSON(http.StatusOK, game)
+	c.JSON(http.StatusOK, game.PlayerView(gc.GameService.Now()))
+	return nil
+}
+
+// ChordCell reveals the neighbours of a revealed cell whose mines are already flagged
+func (gc GameController) ChordCell(c *gin.Context) error {
+
+	boundBody, ok := c.Get("boundBody")
+	if !ok {
+		return &errors.ApiError{Message: "undefined boundBody", ErrorStr: "internal_server_errror", Status: http.StatusInternalServerError}
+	}
+
+	body := boundBody.(*domain.RevealCellRequest)
+	game, err := gc.GameService.ChordCell(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, &errors.ApiError{
+			Message:  err.Error(),
+			ErrorStr: "bad_request",
+			Status:   http.StatusBadRequest,
+		})
+		return nil
+	}
+
+	if game == nil {
+		c.JSON(http.StatusBadRequest, nil)
+		return nil
+	}
+
+	c.JSON(http.StatusOK, game.PlayerView(gc.GameService.Now()))
+	return nil
+}
+
+// UndoMove rolls back the last move of a game
+func (gc GameController) UndoMove(c *gin.Context) error {
+
+	userID, ok := c.Get("userID")
+	if !ok {
+		return &errors.ApiError{Message: "undefined userID", ErrorStr: "internal_server_error", Status: http.StatusInternalServerError}
+	}
+
+	gameID, ok := c.Get("gameID")
+	if !ok {
+		return &errors.ApiError{Message: "undefined gameID", ErrorStr: "internal_server_error", Status: http.StatusInternalServerError}
+	}
+
+	game, err := gc.GameService.UndoMove(fmt.Sprintf("%v", userID), gameID.(int64))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, &errors.ApiError{
+			Message:  err.Error(),
+			ErrorStr: "bad_request",
+			Status:   http.StatusBadRequest,
+		})
+		return nil
+	}
+
+	if game == nil {
+		c.JSON(http.StatusNotFound, &errors.ApiError{
+			Message:  "game not found",
+			ErrorStr: "not_found",
+			Status:   http.StatusNotFound,
+		})
+		return nil
+	}
+
+	c.JSON(http.StatusOK, game.PlayerView(gc.GameService.Now()))
+	return nil
+}
+
+// PauseGame pauses a game, its time stops running
+func (gc GameController) PauseGame(c *gin.Context) error {
+
+	userID, ok := c.Get("userID")
+	if !ok {
+		return &errors.ApiError{Message: "undefined userID", ErrorStr: "internal_server_error", Status: http.StatusInternalServerError}
+	}
+
+	gameID, ok := c.Get("gameID")
+	if !ok {
+		return &errors.ApiError{Message: "undefined gameID", ErrorStr: "internal_server_error", Status: http.StatusInternalServerError}
+	}
+
+	game, err := gc.GameService.PauseGame(fmt.Sprintf("%v", userID), gameID.(int64))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, &errors.ApiError{
+			Message:  err.Error(),
+			ErrorStr: "bad_request",
+			Status:   http.StatusBadRequest,
+		})
+		return nil
+	}
+
+	if game == nil {
+		c.JSON(http.StatusNotFound, &errors.ApiError{
+			Message:  "game not found",
+			ErrorStr: "not_found",
+			Status:   http.StatusNotFound,
+		})
+		return nil
+	}
+
+	c.JSON(http.StatusOK, game.PlayerView(gc.GameService.Now()))
+	return nil
+}
+
+// ResumeGame resumes a paused game
+func (gc GameController) ResumeGame(c *gin.Context) error {
+
+	userID, ok := c.Get("userID")
+	if !ok {
+		return &errors.ApiError{Message: "undefined userID", ErrorStr: "internal_server_error", Status: http.StatusInternalServerError}
+	}
+
+	gameID, ok := c.Get("gameID")
+	if !ok {
+		return &errors.ApiError{Message: "undefined gameID", ErrorStr: "internal_server_error", Status: http.StatusInternalServerError}
+	}
+
+	game, err := gc.GameService.ResumeGame(fmt.Sprintf("%v", userID), gameID.(int64))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, &errors.ApiError{
+			Message:  err.Error(),
+			ErrorStr: "bad_request",
+			Status:   http.StatusBadRequest,
+		})
+		return nil
+	}
+
+	if game == nil {
+		c.JSON(http.StatusNotFound, &errors.ApiError{
+			Message:  "game not found",
+			ErrorStr: "not_found",
+			Status:   http.StatusNotFound,
+		})
+		return nil
+	}
+
+	c.JSON(http.StatusOK, game.PlayerView(gc.GameService.Now()))
+	return nil
+}
+
+// AbandonGame gives up a game, the whole board is sent back
+func (gc GameController) AbandonGame(c *gin.Context) error {
+
+	userID, ok := c.Get("userID")
+	if !ok {
+		return &errors.ApiError{Message: "undefined userID", ErrorStr: "internal_server_error", Status: http.StatusInternalServerError}
+	}
+
+	gameID, ok := c.Get("gameID")
+	if !ok {
+		return &errors.ApiError{Message: "undefined gameID", ErrorStr: "internal_server_error", Status: http.StatusInternalServerError}
+	}
+
+	game, err := gc.GameService.AbandonGame(fmt.Sprintf("%v", userID), gameID.(int64))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, &errors.ApiError{
+			Message:  err.Error(),
+			ErrorStr: "bad_request",
+			Status:   http.StatusBadRequest,
+		})
+		return nil
+	}
+
+	if game == nil {
+		c.JSON(http.StatusNotFound, &errors.ApiError{
+			Message:  "game not found",
+			ErrorStr: "not_found",
+			Status:   http.StatusNotFound,
+		})
+		return nil
+	}
+
+	c.JSON(http.StatusOK, game.PlayerView(gc.GameService.Now()))
+	return nil
+}
+
+// GetUserStats returns the statistics of the games of a user
+func (gc GameController) GetUserStats(c *gin.Context) error {
+
+	userID, ok := c.Get("userID")
+	if !ok {
+		return &errors.ApiError{Message: "undefined userID", ErrorStr: "internal_server_error", Status: http.StatusInternalServerError}
+	}
+
+	stats, err := gc.GameService.GetUserStats(fmt.Sprintf("%v", userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, &errors.ApiError{
+			Message:  err.Error(),
+			ErrorStr: "internal_server_errror",
+			Status:   http.StatusInternalServerError,
+		})
+		return nil
+	}
+
+	if stats == nil {
+		c.JSON(http.StatusNotFound, &errors.ApiError{
+			Message:  "user not found",
+			ErrorStr: "not_found",
+			Status:   http.StatusNotFound,
+		})
+		return nil
+	}
+
+	c.JSON(http.StatusOK, stats)
+	return nil
+}
+
+// GetReplay returns a finished game rebuilt after one of its moves
+func (gc GameController) GetReplay(c *gin.Context) error {
+
+	userID, ok := c.Get("userID")
+	if !ok {
+		return &errors.ApiError{Message: "undefined userID", ErrorStr: "internal_server_error", Status: http.StatusInternalServerError}
+	}
+
+	gameID, ok := c.Get("gameID")
+	if !ok {
+		return &errors.ApiError{Message: "undefined gameID", ErrorStr: "internal_server_error", Status: http.StatusInternalServerError}
+	}
+
+	var move *int
+	if value, ok := c.Get("move"); ok {
+		intMove := value.(int)
+		move = &intMove
+	}
+
+	replay, err := gc.GameService.GetReplay(fmt.Sprintf("%v", userID), gameID.(int64), move)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, &errors.ApiError{
+			Message:  err.Error(),
+			ErrorStr: "bad_request",
+			Status:   http.StatusBadRequest,
+		})
+		return nil
+	}
+
+	if replay == nil {
+		c.JSON(http.StatusNotFound, &errors.ApiError{
+			Message:  "game not found",
+			ErrorStr: "not_found",
+			Status:   http.StatusNotFound,
+		})
+		return nil
+	}
+
+	c.JSON(http.StatusOK, replay)
 	return nil
 }
 
@@ -257,7 +555,12 @@ func (gc GameController) DeleteAllGames(c *gin.Context) error {
 // GetllGames gets all games
 func (gc GameController) GetllGames(c *gin.Context) error {
 
-	games, err := gc.GameService.GetAllGames()
+	query, ok := c.Get("gameQuery")
+	if !ok {
+		return &errors.ApiError{Message: "undefined gameQuery", ErrorStr: "internal_server_error", Status: http.StatusInternalServerError}
+	}
+
+	games, err := gc.GameService.ListAllGames(query.(domain.GameQuery))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, &errors.ApiError{
 			Message:  err.Error(),
@@ -271,33 +574,168 @@ func (gc GameController) GetllGames(c *gin.Context) error {
 	return nil
 }
 
+// GetPresets lists the available presets
+func (gc GameController) GetPresets(c *gin.Context) error {
+	c.JSON(http.StatusOK, gc.GameService.GetPresets())
+	return nil
+}
+
 // ValidateGetGamesByUserID valida el request para obtener una prediccion de usuario
 func (gc GameController) ValidateGetGamesByUserID(c *gin.Context) error {
 
 	// Validate user_id
 	userID := c.Param("user_id")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, &errors.ApiError{"invalid user_id", "bad_request", http.StatusBadRequest})
+		c.JSON(http.StatusBadRequest, &errors.ApiError{Message: "invalid user_id", ErrorStr: "bad_request", Status: http.StatusBadRequest})
 		return nil
 	}
 	c.Set("userID", userID)
 	return nil
 }
 
+// ValidateListUserGames validates the request to list the games of a user
+func (gc GameController) ValidateListUserGames(c *gin.Context) error {
+
+	userID := c.Param("user_id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, &errors.ApiError{Message: "invalid user_id", ErrorStr: "bad_request", Status: http.StatusBadRequest})
+		return nil
+	}
+
+	query, message := parseGameQuery(c)
+	if message != "" {
+		c.JSON(http.StatusBadRequest, &errors.ApiError{Message: message, ErrorStr: "bad_request", Status: http.StatusBadRequest})
+		return nil
+	}
+	query.UserID = userID
+	c.Set("gameQuery", query)
+	return nil
+}
+
+// ValidateListAllGames validates the request to list the games of every user
+func (gc GameController) ValidateListAllGames(c *gin.Context) error {
+
+	query, message := parseGameQuery(c)
+	if message != "" {
+		c.JSON(http.StatusBadRequest, &errors.ApiError{Message: message, ErrorStr: "bad_request", Status: http.StatusBadRequest})
+		return nil
+	}
+	c.Set("gameQuery", query)
+	return nil
+}
+
+// parseGameQuery reads the pagination, filters and sorting of a listing from the query
+// parameters, returning the message of the first invalid one
+func parseGameQuery(c *gin.Context) (domain.GameQuery, string) {
+
+	query := domain.GameQuery{
+		Page:     1,
+		PageSize: constants.DefaultPageSize,
+		SortBy:   constants.SortByStartTime,
+	}
+
+	if page, ok := c.GetQuery("page"); ok {
+		intPage, err := strconv.Atoi(page)
+		if err != nil || intPage < 1 {
+			return query, "invalid page: " + page
+		}
+		query.Page = intPage
+	}
+
+	if pageSize, ok := c.GetQuery("page_size"); ok {
+		intPageSize, err := strconv.Atoi(pageSize)
+		if err != nil || intPageSize < 1 || intPageSize > constants.MaxPageSize {
+			return query, "page_size must be between 1 and " + strconv.Itoa(constants.MaxPageSize)
+		}
+		query.PageSize = intPageSize
+	}
+
+	if statuses, ok := c.GetQuery("status"); ok {
+		for _, status := range strings.Split(statuses, ",") {
+			switch status {
+			case constants.GameStatusCreated, constants.GameStatusOnGoing, constants.GameStatusPaused,
+				constants.GameResultWon, constants.GameStatusLost, constants.GameStatusTimedOut,
+				constants.GameStatusAbandoned:
+				query.Statuses = append(query.Statuses, status)
+			default:
+				return query, "invalid status: " + status
+			}
+		}
+	}
+
+	if rows, ok := c.GetQuery("rows"); ok {
+		intRows, err := strconv.Atoi(rows)
+		if err != nil || intRows < 1 {
+			return query, "invalid rows: " + rows
+		}
+		query.Rows = intRows
+	}
+
+	if columns, ok := c.GetQuery("columns"); ok {
+		intColumns, err := strconv.Atoi(columns)
+		if err != nil || intColumns < 1 {
+			return query, "invalid columns: " + columns
+		}
+		query.Columns = intColumns
+	}
+
+	if from, ok := c.GetQuery("from"); ok {
+		fromTime, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return query, "from must be a RFC 3339 date: " + from
+		}
+		query.From = fromTime
+	}
+
+	if to, ok := c.GetQuery("to"); ok {
+		toTime, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return query, "to must be a RFC 3339 date: " + to
+		}
+		query.To = toTime
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && query.To.Before(query.From) {
+		return query, "to can't be before from"
+	}
+
+	if sortBy, ok := c.GetQuery("sort_by"); ok {
+		if sortBy != constants.SortByStartTime && sortBy != constants.SortByFinishTime && sortBy != constants.SortByMines {
+			return query, "sort_by must be " + constants.SortByStartTime + ", " + constants.SortByFinishTime + " or " + constants.SortByMines
+		}
+		query.SortBy = sortBy
+	}
+
+	if order, ok := c.GetQuery("order"); ok {
+		if order != "asc" && order != "desc" {
+			return query, "order must be asc or desc"
+		}
+		query.Ascending = order == "asc"
+	}
+
+	if omitBoard, ok := c.GetQuery("omit_board"); ok {
+		boolOmitBoard, err := strconv.ParseBool(omitBoard)
+		if err != nil {
+			return query, "invalid omit_board: " + omitBoard
+		}
+		query.OmitBoard = boolOmitBoard
+	}
+	return query, ""
+}
+
 // ValidateGetGameByGameID valida el request para obtener una prediccion de usuario
 func (gc GameController) ValidateGetGameByGameID(c *gin.Context) error {
 
 	// Validate user_id
 	userID := c.Param("user_id")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, &errors.ApiError{"invalid user_id", "bad_request", http.StatusBadRequest})
+		c.JSON(http.StatusBadRequest, &errors.ApiError{Message: "invalid user_id", ErrorStr: "bad_request", Status: http.StatusBadRequest})
 		return nil
 	}
 
 	gameID := c.Param("game_id")
 	intGameID, err := strconv.ParseInt(gameID, 10, 64)
 	if err != nil || intGameID < 0 {
-		c.JSON(http.StatusBadRequest, &errors.ApiError{"invalid game_id: " + err.Error(), "bad_request", http.StatusBadRequest})
+		c.JSON(http.StatusBadRequest, &errors.ApiError{Message: "invalid game_id: " + err.Error(), ErrorStr: "bad_request", Status: http.StatusBadRequest})
 		return nil
 	}
 
@@ -306,6 +744,36 @@ func (gc GameController) ValidateGetGameByGameID(c *gin.Context) error {
 	return nil
 }
 
+// ValidateReplay validates the replay request, the move query parameter is optional
+func (gc GameController) ValidateReplay(c *gin.Context) error {
+
+	userID := c.Param("user_id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, &errors.ApiError{Message: "invalid user_id", ErrorStr: "bad_request", Status: http.StatusBadRequest})
+		return nil
+	}
+
+	gameID := c.Param("game_id")
+	intGameID, err := strconv.ParseInt(gameID, 10, 64)
+	if err != nil || intGameID < 0 {
+		c.JSON(http.StatusBadRequest, &errors.ApiError{Message: "invalid game_id: " + gameID, ErrorStr: "bad_request", Status: http.StatusBadRequest})
+		return nil
+	}
+
+	if move, ok := c.GetQuery("move"); ok {
+		intMove, err := strconv.Atoi(move)
+		if err != nil || intMove < 0 {
+			c.JSON(http.StatusBadRequest, &errors.ApiError{Message: "invalid move: " + move, ErrorStr: "bad_request", Status: http.StatusBadRequest})
+			return nil
+		}
+		c.Set("move", intMove)
+	}
+
+	c.Set("userID", userID)
+	c.Set("gameID", intGameID)
+	return nil
+}
+
 // ValidatePost validates the post request
 func (gc GameController) ValidatePost(c *gin.Context) error {
 
@@ -330,40 +798,157 @@ func (gc GameController) ValidatePost(c *gin.Context) error {
 		return nil
 	}
 
-	if boundBody.Columns <= 0 || boundBody.Columns > 30 {
+	if boundBody.Preset != "" {
+		if boundBody.Rows != 0 || boundBody.Columns != 0 || boundBody.Mines != 0 {
+			c.JSON(http.StatusBadRequest, &errors.ApiError{
+				Message:  "preset can't be combined with rows, columns or mines",
+				ErrorStr: "bad_request",
+				Status:   http.StatusBadRequest,
+			})
+			return nil
+		}
+		preset := gc.GameService.GetPreset(boundBody.Preset)
+		if preset == nil {
+			c.JSON(http.StatusBadRequest, &errors.ApiError{
+				Message:  "unknown preset " + boundBody.Preset + ", the available presets are listed in /minesweeper/presets",
+				ErrorStr: "bad_request",
+				Status:   http.StatusBadRequest,
+			})
+			return nil
+		}
+		boundBody.Rows = preset.Rows
+		boundBody.Columns = preset.Columns
+		boundBody.Mines = preset.Mines
+	}
+
+	if boundBody.Columns <= 0 || boundBody.Columns > gc.Limits.MaxColumns {
+		c.JSON(http.StatusBadRequest, &errors.ApiError{
+			Message:  "columns must be greater than 0 and less or equal than " + strconv.Itoa(gc.Limits.MaxColumns),
+			ErrorStr: "bad_request",
+			Status:   http.StatusBadRequest,
+		})
+		return nil
+	}
+
+	if boundBody.Rows <= 0 || boundBody.Rows > gc.Limits.MaxRows {
 		c.JSON(http.StatusBadRequest, &errors.ApiError{
-			Message:  "columns must be greater than 0 and less or equal than 30",
+			Message:  "rows must be greater than 0 and less or equal than " + strconv.Itoa(gc.Limits.MaxRows),
 			ErrorStr: "bad_request",
 			Status:   http.StatusBadRequest,
 		})
 		return nil
 	}
 
-	if boundBody.Rows <= 0 || boundBody.Rows > 30 {
+	cells := boundBody.Columns * boundBody.Rows
+	if boundBody.Topology == constants.Topology3D && boundBody.Layers > 0 {
+		cells *= boundBody.Layers
+	}
+	if boundBody.Mines <= 0 || boundBody.Mines > cells {
+		minesError := &errors.ApiError{
+			Message:  "the number of mines must be at least one, and less or equal than total of cells in the game",
+			ErrorStr: "bad_request",
+			Status:   http.StatusBadRequest,
+		}
+		c.JSON(http.StatusBadRequest, minesError)
+		return nil
+	}
+
+	if boundBody.FirstClick != "" && boundBody.FirstClick != constants.FirstClickSafeCell && boundBody.FirstClick != constants.FirstClickOpening {
 		c.JSON(http.StatusBadRequest, &errors.ApiError{
-			Message:  "rows must be greater than 0 and less or equal than 30",
+			Message:  "Available first_click options: [" + constants.FirstClickSafeCell + ", " + constants.FirstClickOpening + "]",
 			ErrorStr: "bad_request",
 			Status:   http.StatusBadRequest,
 		})
 		return nil
 	}
 
-	if boundBody.Rows != boundBody.Columns {
+	if boundBody.Topology != "" && boundBody.Topology != constants.TopologySquare && boundBody.Topology != constants.TopologyHex && boundBody.Topology != constants.Topology3D {
 		c.JSON(http.StatusBadRequest, &errors.ApiError{
-			Message:  "rows and columns must be equals",
+			Message:  "Available topology options: [" + constants.TopologySquare + ", " + constants.TopologyHex + ", " + constants.Topology3D + "]",
 			ErrorStr: "bad_request",
 			Status:   http.StatusBadRequest,
 		})
 		return nil
 	}
 
-	if boundBody.Mines <= 0 || boundBody.Mines > boundBody.Columns*boundBody.Rows {
-		minesError := &errors.ApiError{
-			Message:  "the number of mines must be at least one, and less or equal than total of cells in the game",
+	if boundBody.Topology == constants.Topology3D && (boundBody.Layers <= 0 || boundBody.Layers > gc.Limits.MaxLayers) {
+		c.JSON(http.StatusBadRequest, &errors.ApiError{
+			Message:  "layers must be greater than 0 and less or equal than " + strconv.Itoa(gc.Limits.MaxLayers),
 			ErrorStr: "bad_request",
 			Status:   http.StatusBadRequest,
-		}
-		c.JSON(http.StatusBadRequest, minesError)
+		})
+		return nil
+	}
+
+	if boundBody.Topology != constants.Topology3D && boundBody.Layers != 0 {
+		c.JSON(http.StatusBadRequest, &errors.ApiError{
+			Message:  "layers can only be set in " + constants.Topology3D + " boards",
+			ErrorStr: "bad_request",
+			Status:   http.StatusBadRequest,
+		})
+		return nil
+	}
+
+	if boundBody.Wrap && (boundBody.Rows < 3 || boundBody.Columns < 3) {
+		c.JSON(http.StatusBadRequest, &errors.ApiError{
+			Message:  "wrapping boards must have at least 3 rows and 3 columns",
+			ErrorStr: "bad_request",
+			Status:   http.StatusBadRequest,
+		})
+		return nil
+	}
+
+	if boundBody.Wrap && boundBody.Topology == constants.TopologyHex && boundBody.Rows%2 != 0 {
+		c.JSON(http.StatusBadRequest, &errors.ApiError{
+			Message:  "wrapping hexagonal boards must have an even number of rows",
+			ErrorStr: "bad_request",
+			Status:   http.StatusBadRequest,
+		})
+		return nil
+	}
+
+	if boundBody.Wrap && boundBody.Topology == constants.Topology3D && boundBody.Layers < 3 {
+		c.JSON(http.StatusBadRequest, &errors.ApiError{
+			Message:  "wrapping three dimensional boards must have at least 3 layers",
+			ErrorStr: "bad_request",
+			Status:   http.StatusBadRequest,
+		})
+		return nil
+	}
+
+	if boundBody.Lives < 0 || boundBody.Lives > gc.Limits.MaxLives {
+		c.JSON(http.StatusBadRequest, &errors.ApiError{
+			Message:  "lives must be 0 or greater (0 gives a single life) and less or equal than " + strconv.Itoa(gc.Limits.MaxLives),
+			ErrorStr: "bad_request",
+			Status:   http.StatusBadRequest,
+		})
+		return nil
+	}
+
+	if boundBody.TimeLimit < 0 || boundBody.TimeLimit > gc.Limits.MaxTimeLimitSeconds {
+		c.JSON(http.StatusBadRequest, &errors.ApiError{
+			Message:  "time_limit_seconds must be 0 or greater (0 disables it) and less or equal than " + strconv.Itoa(gc.Limits.MaxTimeLimitSeconds),
+			ErrorStr: "bad_request",
+			Status:   http.StatusBadRequest,
+		})
+		return nil
+	}
+
+	if boundBody.Ranked && boundBody.Seed != nil {
+		c.JSON(http.StatusBadRequest, &errors.ApiError{
+			Message:  "ranked games can't be seeded",
+			ErrorStr: "bad_request",
+			Status:   http.StatusBadRequest,
+		})
+		return nil
+	}
+
+	if boundBody.NoGuess && boundBody.FirstClick == constants.FirstClickSafeCell {
+		c.JSON(http.StatusBadRequest, &errors.ApiError{
+			Message:  "no_guess boards always start with an opening",
+			ErrorStr: "bad_request",
+			Status:   http.StatusBadRequest,
+		})
 		return nil
 	}
 
@@ -400,7 +985,7 @@ func (gc GameController) ValidateFlag(c *gin.Context) error {
 	gameID := c.Param("game_id")
 	intGameID, err := strconv.ParseInt(gameID, 10, 64)
 	if err != nil || intGameID < 0 {
-		c.JSON(http.StatusBadRequest, &errors.ApiError{"invalid game_id: " + err.Error(), "bad_request", http.StatusBadRequest})
+		c.JSON(http.StatusBadRequest, &errors.ApiError{Message: "invalid game_id: " + err.Error(), ErrorStr: "bad_request", Status: http.StatusBadRequest})
 		return nil
 	}
 
@@ -422,6 +1007,15 @@ func (gc GameController) ValidateFlag(c *gin.Context) error {
 		return nil
 	}
 
+	if boundBody.Layer < 0 {
+		c.JSON(http.StatusBadRequest, &errors.ApiError{
+			Message:  "layer must be grater than 0",
+			ErrorStr: "bad_request",
+			Status:   http.StatusBadRequest,
+		})
+		return nil
+	}
+
 	if boundBody.Flag != constants.FlagQuestionMark && boundBody.Flag != constants.FlagRedFlag {
 		minesError := &errors.ApiError{
 			Message:  "Available flag options: [" + constants.FlagQuestionMark + ", [" + constants.FlagRedFlag + "]",
@@ -466,7 +1060,7 @@ func (gc GameController) ValidateReveal(c *gin.Context) error {
 	gameID := c.Param("game_id")
 	intGameID, err := strconv.ParseInt(gameID, 10, 64)
 	if err != nil || intGameID < 0 {
-		c.JSON(http.StatusBadRequest, &errors.ApiError{"invalid game_id: " + err.Error(), "bad_request", http.StatusBadRequest})
+		c.JSON(http.StatusBadRequest, &errors.ApiError{Message: "invalid game_id: " + err.Error(), ErrorStr: "bad_request", Status: http.StatusBadRequest})
 		return nil
 	}
 
@@ -488,9 +1082,85 @@ func (gc GameController) ValidateReveal(c *gin.Context) error {
 		return nil
 	}
 
+	if boundBody.Layer < 0 {
+		c.JSON(http.StatusBadRequest, &errors.ApiError{
+			Message:  "layers must be grater than 0",
+			ErrorStr: "bad_request",
+			Status:   http.StatusBadRequest,
+		})
+		return nil
+	}
+
 	boundBody.UserID = userID
 	boundBody.GameID = intGameID
 	c.Set("boundBody", boundBody)
 
 	return nil
 }
+
+// DeleteGame deletes a game of a user
+func (gc GameController) DeleteGame(c *gin.Context) error {
+
+	userID, ok := c.Get("userID")
+	if !ok {
+		return &errors.ApiError{Message: "undefined userID", ErrorStr: "internal_server_error", Status: http.StatusInternalServerError}
+	}
+
+	gameID, ok := c.Get("gameID")
+	if !ok {
+		return &errors.ApiError{Message: "undefined gameID", ErrorStr: "internal_server_error", Status: http.StatusInternalServerError}
+	}
+
+	deleted, err := gc.GameService.DeleteGame(fmt.Sprintf("%v", userID), gameID.(int64))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, &errors.ApiError{
+			Message:  err.Error(),
+			ErrorStr: "internal_server_error",
+			Status:   http.StatusInternalServerError,
+		})
+		return nil
+	}
+
+	if !deleted {
+		c.JSON(http.StatusNotFound, &errors.ApiError{
+			Message:  "game not found",
+			ErrorStr: "not_found",
+			Status:   http.StatusNotFound,
+		})
+		return nil
+	}
+
+	c.JSON(http.StatusOK, nil)
+	return nil
+}
+
+// DeleteUserGames deletes a user and all its games
+func (gc GameController) DeleteUserGames(c *gin.Context) error {
+
+	userID, ok := c.Get("userID")
+	if !ok {
+		return &errors.ApiError{Message: "undefined userID", ErrorStr: "internal_server_error", Status: http.StatusInternalServerError}
+	}
+
+	deleted, err := gc.GameService.DeleteUserGames(fmt.Sprintf("%v", userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, &errors.ApiError{
+			Message:  err.Error(),
+			ErrorStr: "internal_server_error",
+			Status:   http.StatusInternalServerError,
+		})
+		return nil
+	}
+
+	if !deleted {
+		c.JSON(http.StatusNotFound, &errors.ApiError{
+			Message:  "user not found",
+			ErrorStr: "not_found",
+			Status:   http.StatusNotFound,
+		})
+		return nil
+	}
+
+	c.JSON(http.StatusOK, nil)
+	return nil
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mercadolibre/minesweeper/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePostLimits(t *testing.T) {
+	limits := config.Default().Limits
+	cases := []struct {
+		name          string
+		field         string
+		value         int
+		expectedValid bool
+	}{
+		{
+			name:          "FAIL/NEGATIVE_LIVES",
+			field:         "lives",
+			value:         -1,
+			expectedValid: false,
+		},
+		{
+			name:          "OK/DEFAULT_LIVES",
+			field:         "lives",
+			value:         0,
+			expectedValid: true,
+		},
+		{
+			name:          "OK/MAX_LIVES",
+			field:         "lives",
+			value:         limits.MaxLives,
+			expectedValid: true,
+		},
+		{
+			name:          "FAIL/TOO_MANY_LIVES",
+			field:         "lives",
+			value:         limits.MaxLives + 1,
+			expectedValid: false,
+		},
+		{
+			name:          "FAIL/NEGATIVE_TIME_LIMIT",
+			field:         "time_limit_seconds",
+			value:         -1,
+			expectedValid: false,
+		},
+		{
+			name:          "OK/NO_TIME_LIMIT",
+			field:         "time_limit_seconds",
+			value:         0,
+			expectedValid: true,
+		},
+		{
+			name:          "OK/MAX_TIME_LIMIT",
+			field:         "time_limit_seconds",
+			value:         limits.MaxTimeLimitSeconds,
+			expectedValid: true,
+		},
+		{
+			name:          "FAIL/TIME_LIMIT_TOO_LONG",
+			field:         "time_limit_seconds",
+			value:         limits.MaxTimeLimitSeconds + 1,
+			expectedValid: false,
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	gameController := GameController{Limits: limits}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			body := `{"rows": 9, "columns": 9, "mines": 10, "` + c.field + `": ` + strconv.Itoa(c.value) + `}`
+			recorder := httptest.NewRecorder()
+			context, _ := gin.CreateTestContext(recorder)
+			context.Request = httptest.NewRequest(http.MethodPost, "/minesweeper/users/test_user/games", strings.NewReader(body))
+			context.Params = gin.Params{{Key: "user_id", Value: "test_user"}}
+
+			err := gameController.ValidatePost(context)
+			assert.Nil(t, err)
+			_, valid := context.Get("boundBody")
+			assert.Equal(t, valid, c.expectedValid)
+			if !c.expectedValid {
+				assert.Equal(t, recorder.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
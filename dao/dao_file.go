@@ -0,0 +1,452 @@
+package dao
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/mercadolibre/minesweeper/domain"
+)
+
+const (
+	snapshotFileName = "snapshot.json"
+	journalFileName  = "journal.log"
+
+	// journalHeaderSize is the length and the checksum of the payload of a record
+	journalHeaderSize = 8
+
+	// journalMaxRecordSize bounds the length read from a header, a larger one is a torn header
+	journalMaxRecordSize = 64 << 20
+)
+
+// Operations of the journal, one for every change of the container
+const (
+	journalInsert     = "insert"
+	journalInsertGame = "insert_game"
+	journalUpdateGame = "update_game"
+	journalDelete     = "delete"
+	journalDeleteGame = "delete_game"
+	journalDeleteAll  = "delete_all"
+)
+
+// journalRecord is a change of the container. Seq grows with every record and is never reused,
+// so the records already compacted into the snapshot can be told apart
+type journalRecord struct {
+	Seq      uint64           `json:"seq"`
+	Op       string           `json:"op"`
+	UserID   string           `json:"user_id,omitempty"`
+	GameID   int64            `json:"game_id,omitempty"`
+	UserGame *domain.UserGame `json:"user_game,omitempty"`
+	Game     *domain.Game     `json:"game,omitempty"`
+}
+
+// snapshot is the whole content of the container after the record Seq
+type snapshot struct {
+	Seq       uint64             `json:"seq"`
+	UserGames []*domain.UserGame `json:"user_games"`
+}
+
+// FileContainer keeps the games in memory and journals every change to an append-only log on
+// disk before applying it. Every snapshotEvery records the games are written to a snapshot and
+// the log is emptied. On startup the snapshot is loaded and the log replayed on top of it.
+// The games read and written are copies, so the games in memory only change through the journal
+type FileContainer struct {
+	memory        *InMemoryContainer
+	dir           string
+	journal       *os.File
+	seq           uint64
+	pending       int
+	snapshotEvery int
+	// mutex is held to write, and to read so the games are not copied while being changed
+	mutex *sync.RWMutex
+}
+
+// CreateFileContainer opens the store of the directory, creating it if it doesn't exist, and
+// restores its games. A record cut short by a crash at the end of the log is discarded
+func CreateFileContainer(dir string, snapshotEvery int) (*FileContainer, error) {
+
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	container := &FileContainer{
+		memory:        CreateInMemoryContainer(),
+		dir:           dir,
+		snapshotEvery: snapshotEvery,
+		mutex:         &sync.RWMutex{},
+	}
+	err = container.loadSnapshot()
+	if err != nil {
+		return nil, fmt.Errorf("loading the snapshot: %v", err)
+	}
+	err = container.replayJournal()
+	if err != nil {
+		return nil, fmt.Errorf("replaying the journal: %v", err)
+	}
+	return container, nil
+}
+
+// Close closes the journal, the container can't be changed afterwards
+func (fc *FileContainer) Close() error {
+
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	return fc.journal.Close()
+}
+
+// Get gets a copy of the games of a user
+func (fc *FileContainer) Get(userID string) (*domain.UserGame, error) {
+
+	fc.mutex.RLock()
+	defer fc.mutex.RUnlock()
+	userGame, err := fc.memory.Get(userID)
+	if err != nil || userGame == nil {
+		return nil, err
+	}
+	userGameCopy := &domain.UserGame{}
+	return userGameCopy, copyThroughJSON(userGame, userGameCopy)
+}
+
+// GetAll gets a copy of all games, grouped by user
+func (fc *FileContainer) GetAll() ([]*domain.UserGame, error) {
+
+	fc.mutex.RLock()
+	defer fc.mutex.RUnlock()
+	userGames, err := fc.memory.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	copies := make([]*domain.UserGame, 0, len(userGames))
+	for _, userGame := range userGames {
+		userGameCopy := &domain.UserGame{}
+		err = copyThroughJSON(userGame, userGameCopy)
+		if err != nil {
+			return nil, err
+		}
+		copies = append(copies, userGameCopy)
+	}
+	return copies, nil
+}
+
+// Find returns a copy of a page of the games matching the query and the number of games matching it
+func (fc *FileContainer) Find(query domain.GameQuery) ([]*domain.Game, int, error) {
+
+	fc.mutex.RLock()
+	defer fc.mutex.RUnlock()
+	games, total, err := fc.memory.Find(query)
+	if err != nil {
+		return nil, 0, err
+	}
+	copies := make([]*domain.Game, 0, len(games))
+	for _, game := range games {
+		gameCopy := &domain.Game{}
+		err = copyThroughJSON(game, gameCopy)
+		if err != nil {
+			return nil, 0, err
+		}
+		copies = append(copies, gameCopy)
+	}
+	return copies, total, nil
+}
+
+// Insert replaces all the games of the user with the games of the userGame
+func (fc *FileContainer) Insert(userGame *domain.UserGame) error {
+	_, err := fc.write(&journalRecord{Op: journalInsert, UserGame: userGame})
+	return err
+}
+
+// InsertGame stores a new game of the user
+func (fc *FileContainer) InsertGame(userID string, game *domain.Game) error {
+	_, err := fc.write(&journalRecord{Op: journalInsertGame, UserID: userID, Game: game})
+	return err
+}
+
+// UpdateGame replaces the stored game with the same id
+func (fc *FileContainer) UpdateGame(userID string, game *domain.Game) error {
+	_, err := fc.write(&journalRecord{Op: journalUpdateGame, UserID: userID, Game: game})
+	return err
+}
+
+// Delete deletes all the games of the user, returning false if the user was not stored
+func (fc *FileContainer) Delete(userID string) (bool, error) {
+	return fc.write(&journalRecord{Op: journalDelete, UserID: userID})
+}
+
+// DeleteGame deletes a game of a user, returning false if the game was not stored
+func (fc *FileContainer) DeleteGame(userID string, gameID int64) (bool, error) {
+	return fc.write(&journalRecord{Op: journalDeleteGame, UserID: userID, GameID: gameID})
+}
+
+// DeleteAll deletes all the games
+func (fc *FileContainer) DeleteAll() error {
+	_, err := fc.write(&journalRecord{Op: journalDeleteAll})
+	return err
+}
+
+// Compact writes the games to a new snapshot and empties the journal
+func (fc *FileContainer) Compact() error {
+
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	return fc.compact()
+}
+
+// write appends the record to the journal, syncing it to disk, and then applies the record read
+// back from the journal to the games. The change is not applied if it could not be journaled
+func (fc *FileContainer) write(record *journalRecord) (bool, error) {
+
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+
+	record.Seq = fc.seq + 1
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return false, err
+	}
+	frame := make([]byte, journalHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(frame[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(frame[4:8], crc32.ChecksumIEEE(payload))
+	copy(frame[journalHeaderSize:], payload)
+
+	_, err = fc.journal.Write(frame)
+	if err == nil {
+		err = fc.journal.Sync()
+	}
+	if err != nil {
+		return false, fmt.Errorf("writing the journal: %v", err)
+	}
+	fc.seq = record.Seq
+
+	// The games of the caller are not kept, they could be changed without being journaled
+	journaled := &journalRecord{}
+	err = json.Unmarshal(payload, journaled)
+	if err != nil {
+		return false, err
+	}
+	changed, err := fc.apply(journaled)
+	if err != nil {
+		return false, err
+	}
+	fc.pending++
+	if fc.snapshotEvery > 0 && fc.pending >= fc.snapshotEvery {
+		// The change is already durable in the journal, a failed compaction is retried
+		// with the next change
+		err = fc.compact()
+		if err != nil {
+			log.Printf("compacting the games of %s: %v", fc.dir, err)
+		}
+	}
+	return changed, nil
+}
+
+// apply changes the games in memory as the record says
+func (fc *FileContainer) apply(record *journalRecord) (bool, error) {
+
+	switch record.Op {
+	case journalInsert:
+		return true, fc.memory.Insert(record.UserGame)
+	case journalInsertGame:
+		return true, fc.memory.InsertGame(record.UserID, record.Game)
+	case journalUpdateGame:
+		return true, fc.memory.UpdateGame(record.UserID, record.Game)
+	case journalDelete:
+		return fc.memory.Delete(record.UserID)
+	case journalDeleteGame:
+		return fc.memory.DeleteGame(record.UserID, record.GameID)
+	case journalDeleteAll:
+		return true, fc.memory.DeleteAll()
+	default:
+		return false, fmt.Errorf("unknown journal operation %s", record.Op)
+	}
+}
+
+// compact replaces the snapshot atomically before emptying the journal. If it stops in between,
+// the records left in the journal are already in the snapshot and are skipped by their seq
+func (fc *FileContainer) compact() error {
+
+	userGames, err := fc.memory.GetAll()
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(&snapshot{Seq: fc.seq, UserGames: userGames})
+	if err != nil {
+		return err
+	}
+
+	tmpPath := filepath.Join(fc.dir, snapshotFileName+".tmp")
+	tmpFile, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	_, err = tmpFile.Write(content)
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	err = os.Rename(tmpPath, filepath.Join(fc.dir, snapshotFileName))
+	if err != nil {
+		return err
+	}
+	err = syncDir(fc.dir)
+	if err != nil {
+		return err
+	}
+
+	err = fc.journal.Truncate(0)
+	if err != nil {
+		return err
+	}
+	_, err = fc.journal.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	fc.pending = 0
+	return nil
+}
+
+// loadSnapshot restores the games of the snapshot, if there is one
+func (fc *FileContainer) loadSnapshot() error {
+
+	content, err := ioutil.ReadFile(filepath.Join(fc.dir, snapshotFileName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	stored := &snapshot{}
+	err = json.Unmarshal(content, stored)
+	if err != nil {
+		return err
+	}
+	for _, userGame := range stored.UserGames {
+		fc.memory.Insert(userGame)
+	}
+	fc.seq = stored.Seq
+	return nil
+}
+
+// replayJournal applies the records of the journal newer than the snapshot and leaves it open
+// to append new ones. The journal is cut at the first record that is incomplete or doesn't
+// match its checksum, the one being written when the process stopped
+func (fc *FileContainer) replayJournal() error {
+
+	journal, err := os.OpenFile(filepath.Join(fc.dir, journalFileName), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	var valid int64
+	reader := bufio.NewReader(journal)
+	for {
+		record, size, err := readRecord(reader)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			journal.Close()
+			return err
+		}
+		if record == nil {
+			break
+		}
+		valid += size
+		if record.Seq <= fc.seq {
+			continue
+		}
+		_, err = fc.apply(record)
+		if err != nil {
+			journal.Close()
+			return err
+		}
+		fc.seq = record.Seq
+		fc.pending++
+	}
+
+	err = journal.Truncate(valid)
+	if err == nil {
+		_, err = journal.Seek(valid, io.SeekStart)
+	}
+	if err != nil {
+		journal.Close()
+		return err
+	}
+	fc.journal = journal
+	return nil
+}
+
+// readRecord reads the next record of the journal and its size on disk. It returns io.EOF at
+// the end of the journal and a nil record if the rest of the journal is torn
+func readRecord(reader io.Reader) (*journalRecord, int64, error) {
+
+	header := make([]byte, journalHeaderSize)
+	n, err := io.ReadFull(reader, header)
+	if err == io.EOF {
+		return nil, 0, io.EOF
+	}
+	if err == io.ErrUnexpectedEOF {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	size := binary.BigEndian.Uint32(header[0:4])
+	if size > journalMaxRecordSize {
+		return nil, 0, nil
+	}
+	payload := make([]byte, size)
+	_, err = io.ReadFull(reader, payload)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, 0, nil
+	}
+
+	record := &journalRecord{}
+	if json.Unmarshal(payload, record) != nil {
+		return nil, 0, nil
+	}
+	return record, int64(n + len(payload)), nil
+}
+
+// copyThroughJSON deep copies the games of src to dst through their journal encoding
+func copyThroughJSON(src, dst interface{}) error {
+
+	content, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, dst)
+}
+
+// syncDir makes the renames inside the directory durable
+func syncDir(dir string) error {
+
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,217 @@
+package dao
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/mercadolibre/minesweeper/constants"
+	"github.com/mercadolibre/minesweeper/domain"
+	"github.com/mercadolibre/minesweeper/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileContainerRestart(t *testing.T) {
+	for _, snapshotEvery := range []int{0, 1, 2} {
+		dir, _ := ioutil.TempDir("", "dao")
+		defer os.RemoveAll(dir)
+
+		container, err := CreateFileContainer(dir, snapshotEvery)
+		assert.Nil(t, err)
+		container.Insert(&domain.UserGame{UserID: "test_user_1", Games: []*domain.Game{{GameID: 1}}})
+		container.InsertGame("test_user_1", &domain.Game{GameID: 2, Status: constants.GameStatusCreated})
+		container.InsertGame("test_user_2", &domain.Game{GameID: 3, Status: constants.GameStatusCreated})
+		container.UpdateGame("test_user_1", &domain.Game{GameID: 2, Status: constants.GameResultWon})
+		container.DeleteGame("test_user_1", 1)
+		container.Insert(&domain.UserGame{UserID: "test_user_3", Games: []*domain.Game{{GameID: 4}}})
+		container.Delete("test_user_3")
+		expected, _ := container.GetAll()
+		container.Close()
+
+		container, err = CreateFileContainer(dir, snapshotEvery)
+		assert.Nil(t, err)
+		userGames, _ := container.GetAll()
+		assert.Equal(t, userGames, expected)
+
+		container.DeleteAll()
+		container.Close()
+		container, _ = CreateFileContainer(dir, snapshotEvery)
+		userGames, _ = container.GetAll()
+		assert.Empty(t, userGames)
+		container.Close()
+	}
+}
+
+func TestFileContainerTornJournal(t *testing.T) {
+
+	cases := []struct {
+		name string
+		// tear damages the journal, whose last record starts at lastRecord
+		tear func(journal []byte, lastRecord int) []byte
+	}{
+		{
+			name: "OK/CUT_IN_HEADER",
+			tear: func(journal []byte, lastRecord int) []byte {
+				return journal[:lastRecord+3]
+			},
+		},
+		{
+			name: "OK/CUT_IN_PAYLOAD",
+			tear: func(journal []byte, lastRecord int) []byte {
+				return journal[:lastRecord+journalHeaderSize+5]
+			},
+		},
+		{
+			name: "OK/CUT_LAST_BYTE",
+			tear: func(journal []byte, lastRecord int) []byte {
+				return journal[:len(journal)-1]
+			},
+		},
+		{
+			name: "OK/CORRUPT_PAYLOAD",
+			tear: func(journal []byte, lastRecord int) []byte {
+				journal[len(journal)-2] ^= 0xff
+				return journal
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir, _ := ioutil.TempDir("", "dao")
+			defer os.RemoveAll(dir)
+			journalPath := filepath.Join(dir, journalFileName)
+
+			container, err := CreateFileContainer(dir, 0)
+			assert.Nil(t, err)
+			container.InsertGame("test_user_1", &domain.Game{GameID: 1})
+			container.InsertGame("test_user_1", &domain.Game{GameID: 2})
+			info, _ := os.Stat(journalPath)
+			lastRecord := int(info.Size())
+			container.InsertGame("test_user_1", &domain.Game{GameID: 3})
+			container.Close()
+
+			journal, _ := ioutil.ReadFile(journalPath)
+			ioutil.WriteFile(journalPath, c.tear(journal, lastRecord), 0644)
+
+			container, err = CreateFileContainer(dir, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, gameIDs(container, "test_user_1"), []int64{1, 2})
+
+			// The torn record is dropped from the journal, so the new records are read back
+			container.InsertGame("test_user_1", &domain.Game{GameID: 4})
+			container.Close()
+			container, err = CreateFileContainer(dir, 0)
+			assert.Nil(t, err)
+			assert.Equal(t, gameIDs(container, "test_user_1"), []int64{1, 2, 4})
+			container.Close()
+		})
+	}
+}
+
+func TestFileContainerCompaction(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "dao")
+	defer os.RemoveAll(dir)
+	journalPath := filepath.Join(dir, journalFileName)
+
+	container, err := CreateFileContainer(dir, 0)
+	assert.Nil(t, err)
+	container.InsertGame("test_user_1", &domain.Game{GameID: 1})
+	container.InsertGame("test_user_1", &domain.Game{GameID: 2})
+	journal, _ := ioutil.ReadFile(journalPath)
+	assert.Nil(t, container.Compact())
+	info, _ := os.Stat(journalPath)
+	assert.Equal(t, info.Size(), int64(0))
+	container.InsertGame("test_user_1", &domain.Game{GameID: 3})
+	container.Close()
+
+	container, err = CreateFileContainer(dir, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, gameIDs(container, "test_user_1"), []int64{1, 2, 3})
+	container.Close()
+
+	// A crash after the snapshot was written but before the journal was emptied leaves records
+	// that are already in the snapshot, they must not be applied twice
+	container, _ = CreateFileContainer(dir, 0)
+	assert.Nil(t, container.Compact())
+	container.Close()
+	ioutil.WriteFile(journalPath, journal, 0644)
+
+	container, err = CreateFileContainer(dir, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, gameIDs(container, "test_user_1"), []int64{1, 2, 3})
+	container.InsertGame("test_user_1", &domain.Game{GameID: 4})
+	container.Close()
+	container, _ = CreateFileContainer(dir, 0)
+	assert.Equal(t, gameIDs(container, "test_user_1"), []int64{1, 2, 3, 4})
+	container.Close()
+}
+
+func TestFileContainerFailedWrite(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "dao")
+	defer os.RemoveAll(dir)
+
+	container, err := CreateFileContainer(dir, 0)
+	assert.Nil(t, err)
+	game := &domain.Game{GameID: 1, Status: constants.GameStatusCreated}
+	container.InsertGame("test_user_1", game)
+
+	// The service changes the games it reads, and the games it stored, before updating them
+	game.Status = constants.GameResultWon
+	userGame, _ := container.Get("test_user_1")
+	userGame.Games[0].Status = constants.GameStatusOnGoing
+	container.journal.Close()
+	err = container.UpdateGame("test_user_1", userGame.Games[0])
+	assert.NotNil(t, err)
+
+	userGame, _ = container.Get("test_user_1")
+	assert.Equal(t, userGame.Games[0].Status, constants.GameStatusCreated)
+	userGames, _ := container.GetAll()
+	assert.Equal(t, userGames[0].Games[0].Status, constants.GameStatusCreated)
+}
+
+func TestFileContainerConcurrentAccess(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "dao")
+	defer os.RemoveAll(dir)
+
+	container, err := CreateFileContainer(dir, 5)
+	assert.Nil(t, err)
+	defer container.Close()
+
+	// Run with -race: the reads copy the games while the writes change them
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := int64(1); i <= 50; i++ {
+			container.InsertGame("test_user_1", &domain.Game{GameID: i, Status: constants.GameStatusCreated})
+			container.UpdateGame("test_user_1", &domain.Game{GameID: i, Status: constants.GameStatusOnGoing})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			container.Get("test_user_1")
+			container.GetAll()
+			container.Find(domain.GameQuery{UserID: "test_user_1", Page: 1, PageSize: 10})
+		}
+	}()
+	wg.Wait()
+
+	assert.Equal(t, len(gameIDs(container, "test_user_1")), 50)
+}
+
+func gameIDs(container services.GameRepository, userID string) []int64 {
+
+	ids := make([]int64, 0)
+	userGame, _ := container.Get(userID)
+	if userGame == nil {
+		return ids
+	}
+	for _, game := range userGame.Games {
+		ids = append(ids, game.GameID)
+	}
+	return ids
+}
@@ -1,8 +1,10 @@
 package dao
 
 import (
+	"sort"
 	"sync"
 
+	"github.com/mercadolibre/minesweeper/constants"
 	"github.com/mercadolibre/minesweeper/domain"
 )
 
@@ -25,6 +27,9 @@ func CreateInMemoryContainer() *InMemoryContainer {
 // Get gets a game from a userID
 func (imc *InMemoryContainer) Get(userID string) (*domain.UserGame, error) {
 
+	imc.mutex.Lock()
+	defer imc.mutex.Unlock()
+
 	for _, userGame := range imc.userGames {
 		if userGame.UserID == userID {
 			return userGame, nil
@@ -35,30 +40,66 @@ func (imc *InMemoryContainer) Get(userID string) (*domain.UserGame, error) {
 
 // GetAll gets all games from a userID
 func (imc *InMemoryContainer) GetAll() ([]*domain.UserGame, error) {
-	return imc.userGames, nil
+
+	imc.mutex.Lock()
+	defer imc.mutex.Unlock()
+
+	userGames := make([]*domain.UserGame, len(imc.userGames))
+	copy(userGames, imc.userGames)
+	return userGames, nil
 }
 
-// Update updates a game
-func (imc *InMemoryContainer) Update(userGame *domain.UserGame) error {
+// Insert inserts a new userGame, replacing the games of the user if it was already stored
+func (imc *InMemoryContainer) Insert(userGame *domain.UserGame) error {
 
 	imc.mutex.Lock()
 	defer imc.mutex.Unlock()
 
 	for i, user := range imc.userGames {
 		if user.UserID == userGame.UserID {
-			imc.userGames[i].Games = userGame.Games
+			imc.userGames[i] = userGame
+			return nil
 		}
 	}
+	imc.userGames = append(imc.userGames, userGame)
 	return nil
 }
 
-// Insert inserts a new userGame
-func (imc *InMemoryContainer) Insert(userGame *domain.UserGame) error {
+// InsertGame adds a game to the games of the user, storing the user if it was not stored
+func (imc *InMemoryContainer) InsertGame(userID string, game *domain.Game) error {
 
 	imc.mutex.Lock()
 	defer imc.mutex.Unlock()
 
-	imc.userGames = append(imc.userGames, userGame)
+	for _, user := range imc.userGames {
+		if user.UserID == userID {
+			user.Games = append(user.Games, game)
+			return nil
+		}
+	}
+	imc.userGames = append(imc.userGames, &domain.UserGame{
+		UserID: userID,
+		Games:  []*domain.Game{game},
+	})
+	return nil
+}
+
+// UpdateGame replaces the stored game with the same id
+func (imc *InMemoryContainer) UpdateGame(userID string, game *domain.Game) error {
+
+	imc.mutex.Lock()
+	defer imc.mutex.Unlock()
+
+	for _, user := range imc.userGames {
+		if user.UserID != userID {
+			continue
+		}
+		for i, storedGame := range user.Games {
+			if storedGame.GameID == game.GameID {
+				user.Games[i] = game
+			}
+		}
+	}
 	return nil
 }
 
@@ -70,3 +111,133 @@ func (imc *InMemoryContainer) DeleteAll() error {
 	imc.userGames = nil
 	return nil
 }
+
+// Delete deletes the user and all its games, returning false if the user was not stored
+func (imc *InMemoryContainer) Delete(userID string) (bool, error) {
+	imc.mutex.Lock()
+	defer imc.mutex.Unlock()
+
+	for i, user := range imc.userGames {
+		if user.UserID == userID {
+			imc.userGames = append(imc.userGames[:i], imc.userGames[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// DeleteGame deletes a game of a user, returning false if the game was not stored. The user is
+// deleted with its last game, as the other containers have no users without games
+func (imc *InMemoryContainer) DeleteGame(userID string, gameID int64) (bool, error) {
+	imc.mutex.Lock()
+	defer imc.mutex.Unlock()
+
+	for u, user := range imc.userGames {
+		if user.UserID != userID {
+			continue
+		}
+		for i, game := range user.Games {
+			if game.GameID == gameID {
+				user.Games = append(user.Games[:i:i], user.Games[i+1:]...)
+				if len(user.Games) == 0 {
+					imc.userGames = append(imc.userGames[:u], imc.userGames[u+1:]...)
+				}
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// Find returns a page of the games matching the query and the number of games matching it.
+// The games are copies carrying the id of their user
+func (imc *InMemoryContainer) Find(query domain.GameQuery) ([]*domain.Game, int, error) {
+	imc.mutex.Lock()
+	defer imc.mutex.Unlock()
+
+	matching := make([]*domain.Game, 0)
+	for _, user := range imc.userGames {
+		if query.UserID != "" && user.UserID != query.UserID {
+			continue
+		}
+		for _, game := range user.Games {
+			if matchesQuery(game, query) {
+				gameCopy := *game
+				gameCopy.UserID = user.UserID
+				matching = append(matching, &gameCopy)
+			}
+		}
+	}
+	sort.SliceStable(matching, func(i, j int) bool {
+		if query.Ascending {
+			return lessByQuery(matching[i], matching[j], query)
+		}
+		return lessByQuery(matching[j], matching[i], query)
+	})
+
+	start := (query.Page - 1) * query.PageSize
+	if start > len(matching) {
+		start = len(matching)
+	}
+	end := start + query.PageSize
+	if end > len(matching) {
+		end = len(matching)
+	}
+	page := matching[start:end]
+	if query.OmitBoard {
+		for _, game := range page {
+			game.Board = nil
+			game.Board3D = nil
+			game.MineLocations = nil
+		}
+	}
+	return page, len(matching), nil
+}
+
+func matchesQuery(game *domain.Game, query domain.GameQuery) bool {
+
+	if len(query.Statuses) > 0 {
+		found := false
+		for _, status := range query.Statuses {
+			if game.Status == status {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if query.Rows > 0 && game.Rows != query.Rows {
+		return false
+	}
+	if query.Columns > 0 && game.Columns != query.Columns {
+		return false
+	}
+	if !query.From.IsZero() && game.Start.Before(query.From) {
+		return false
+	}
+	if !query.To.IsZero() && game.Start.After(query.To) {
+		return false
+	}
+	return true
+}
+
+// lessByQuery sorts by the field of the query and then by game id
+func lessByQuery(a, b *domain.Game, query domain.GameQuery) bool {
+
+	switch query.SortBy {
+	case constants.SortByFinishTime:
+		if !a.Finish.Equal(b.Finish) {
+			return a.Finish.Before(b.Finish)
+		}
+	case constants.SortByMines:
+		if a.Mines != b.Mines {
+			return a.Mines < b.Mines
+		}
+	default:
+		if !a.Start.Equal(b.Start) {
+			return a.Start.Before(b.Start)
+		}
+	}
+	return a.GameID < b.GameID
+}
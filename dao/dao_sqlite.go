@@ -0,0 +1,319 @@
+package dao
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/mercadolibre/minesweeper/constants"
+	"github.com/mercadolibre/minesweeper/domain"
+
+	// registers the sqlite3 driver
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteTimeLayout stores the times in UTC with a fixed width, so they sort as text
+const sqliteTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// sqliteMigrations are the changes of the schema, in order. The version of a database is the
+// number of migrations applied to it, new migrations must be appended
+var sqliteMigrations = []string{
+	`CREATE TABLE games (
+		user_id     TEXT    NOT NULL,
+		game_id     INTEGER NOT NULL,
+		status      TEXT    NOT NULL,
+		rows        INTEGER NOT NULL,
+		columns     INTEGER NOT NULL,
+		mines       INTEGER NOT NULL,
+		start_time  TEXT    NOT NULL,
+		finish_time TEXT    NOT NULL,
+		data        TEXT    NOT NULL,
+		PRIMARY KEY (user_id, game_id)
+	);
+	CREATE INDEX games_user_start_time ON games (user_id, start_time);
+	CREATE INDEX games_status_start_time ON games (status, start_time);
+	CREATE INDEX games_start_time ON games (start_time);`,
+}
+
+// SQLiteContainer stores every game in a row of a SQLite database. The columns used to filter
+// and sort the games are kept next to the whole game, stored as JSON
+type SQLiteContainer struct {
+	db *sql.DB
+}
+
+// CreateSQLiteContainer opens the SQLite database of the path, creating it if it doesn't exist,
+// and migrates its schema to the latest version
+func CreateSQLiteContainer(path string) (*SQLiteContainer, error) {
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite has a single writer, sharing one connection avoids locking errors
+	db.SetMaxOpenConns(1)
+
+	container := &SQLiteContainer{db: db}
+	err = container.migrate()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return container, nil
+}
+
+// Close closes the database
+func (sc *SQLiteContainer) Close() error {
+	return sc.db.Close()
+}
+
+// migrate applies the migrations that were not applied yet, each one in its own transaction
+func (sc *SQLiteContainer) migrate() error {
+
+	_, err := sc.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER NOT NULL)`)
+	if err != nil {
+		return err
+	}
+	var version int
+	err = sc.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	if err != nil {
+		return err
+	}
+
+	for ; version < len(sqliteMigrations); version++ {
+		tx, err := sc.db.Begin()
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(sqliteMigrations[version])
+		if err == nil {
+			_, err = tx.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, version+1)
+		}
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		err = tx.Commit()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get gets the games of a user, nil if the user has no games
+func (sc *SQLiteContainer) Get(userID string) (*domain.UserGame, error) {
+
+	games, err := sc.queryGames(`SELECT user_id, data FROM games WHERE user_id = ? ORDER BY start_time, game_id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(games) == 0 {
+		return nil, nil
+	}
+	return &domain.UserGame{UserID: userID, Games: games}, nil
+}
+
+// GetAll gets all games, grouped by user
+func (sc *SQLiteContainer) GetAll() ([]*domain.UserGame, error) {
+
+	games, err := sc.queryGames(`SELECT user_id, data FROM games ORDER BY user_id, start_time, game_id`)
+	if err != nil {
+		return nil, err
+	}
+
+	var userGames []*domain.UserGame
+	for _, game := range games {
+		if len(userGames) == 0 || userGames[len(userGames)-1].UserID != game.UserID {
+			userGames = append(userGames, &domain.UserGame{UserID: game.UserID})
+		}
+		userGame := userGames[len(userGames)-1]
+		userGame.Games = append(userGame.Games, game)
+	}
+	return userGames, nil
+}
+
+// Find returns a page of the games matching the query and the number of games matching it
+func (sc *SQLiteContainer) Find(query domain.GameQuery) ([]*domain.Game, int, error) {
+
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+	if query.UserID != "" {
+		conditions = append(conditions, "user_id = ?")
+		args = append(args, query.UserID)
+	}
+	if len(query.Statuses) > 0 {
+		conditions = append(conditions, "status IN (?"+strings.Repeat(", ?", len(query.Statuses)-1)+")")
+		for _, status := range query.Statuses {
+			args = append(args, status)
+		}
+	}
+	if query.Rows > 0 {
+		conditions = append(conditions, "rows = ?")
+		args = append(args, query.Rows)
+	}
+	if query.Columns > 0 {
+		conditions = append(conditions, "columns = ?")
+		args = append(args, query.Columns)
+	}
+	if !query.From.IsZero() {
+		conditions = append(conditions, "start_time >= ?")
+		args = append(args, sqliteTime(query.From))
+	}
+	if !query.To.IsZero() {
+		conditions = append(conditions, "start_time <= ?")
+		args = append(args, sqliteTime(query.To))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	err := sc.db.QueryRow(`SELECT COUNT(*) FROM games`+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	order := " DESC"
+	if query.Ascending {
+		order = " ASC"
+	}
+	sortBy := constants.SortByStartTime
+	if query.SortBy == constants.SortByFinishTime || query.SortBy == constants.SortByMines {
+		sortBy = query.SortBy
+	}
+	games, err := sc.queryGames(
+		`SELECT user_id, data FROM games`+where+` ORDER BY `+sortBy+order+`, game_id`+order+` LIMIT ? OFFSET ?`,
+		append(args, query.PageSize, (query.Page-1)*query.PageSize)...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	if query.OmitBoard {
+		for _, game := range games {
+			game.Board = nil
+			game.Board3D = nil
+			game.MineLocations = nil
+		}
+	}
+	return games, total, nil
+}
+
+// Insert replaces all the games of the user with the games of the userGame
+func (sc *SQLiteContainer) Insert(userGame *domain.UserGame) error {
+
+	tx, err := sc.db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`DELETE FROM games WHERE user_id = ?`, userGame.UserID)
+	for i := 0; err == nil && i < len(userGame.Games); i++ {
+		err = insertGame(tx, userGame.UserID, userGame.Games[i])
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// InsertGame stores a new game of the user
+func (sc *SQLiteContainer) InsertGame(userID string, game *domain.Game) error {
+	return insertGame(sc.db, userID, game)
+}
+
+// UpdateGame replaces the row of the game, leaving the other games of the user untouched
+func (sc *SQLiteContainer) UpdateGame(userID string, game *domain.Game) error {
+
+	data, err := json.Marshal(game)
+	if err != nil {
+		return err
+	}
+	_, err = sc.db.Exec(
+		`UPDATE games SET status = ?, rows = ?, columns = ?, mines = ?, start_time = ?, finish_time = ?, data = ?
+		WHERE user_id = ? AND game_id = ?`,
+		game.Status, game.Rows, game.Columns, game.Mines, sqliteTime(game.Start), sqliteTime(game.Finish), string(data),
+		userID, game.GameID,
+	)
+	return err
+}
+
+// Delete deletes all the games of the user, returning false if the user had no games
+func (sc *SQLiteContainer) Delete(userID string) (bool, error) {
+
+	result, err := sc.db.Exec(`DELETE FROM games WHERE user_id = ?`, userID)
+	if err != nil {
+		return false, err
+	}
+	deleted, err := result.RowsAffected()
+	return deleted > 0, err
+}
+
+// DeleteGame deletes a game of a user, returning false if the game was not stored
+func (sc *SQLiteContainer) DeleteGame(userID string, gameID int64) (bool, error) {
+
+	result, err := sc.db.Exec(`DELETE FROM games WHERE user_id = ? AND game_id = ?`, userID, gameID)
+	if err != nil {
+		return false, err
+	}
+	deleted, err := result.RowsAffected()
+	return deleted > 0, err
+}
+
+// DeleteAll deletes all the games
+func (sc *SQLiteContainer) DeleteAll() error {
+	_, err := sc.db.Exec(`DELETE FROM games`)
+	return err
+}
+
+// queryGames decodes the games of the rows, each one carrying the id of its user
+func (sc *SQLiteContainer) queryGames(query string, args ...interface{}) ([]*domain.Game, error) {
+
+	rows, err := sc.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	games := make([]*domain.Game, 0)
+	for rows.Next() {
+		var userID, data string
+		err = rows.Scan(&userID, &data)
+		if err != nil {
+			return nil, err
+		}
+		game := &domain.Game{}
+		err = json.Unmarshal([]byte(data), game)
+		if err != nil {
+			return nil, err
+		}
+		game.UserID = userID
+		games = append(games, game)
+	}
+	return games, rows.Err()
+}
+
+// execer runs statements either on the database or inside a transaction
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func insertGame(db execer, userID string, game *domain.Game) error {
+
+	data, err := json.Marshal(game)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(
+		`INSERT INTO games (user_id, game_id, status, rows, columns, mines, start_time, finish_time, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		userID, game.GameID, game.Status, game.Rows, game.Columns, game.Mines,
+		sqliteTime(game.Start), sqliteTime(game.Finish), string(data),
+	)
+	return err
+}
+
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeLayout)
+}
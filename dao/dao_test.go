@@ -1,13 +1,62 @@
 package dao
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/mercadolibre/minesweeper/constants"
 	"github.com/mercadolibre/minesweeper/domain"
+	"github.com/mercadolibre/minesweeper/services"
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
 )
 
+// Every container must keep implementing every operation the service needs
+var (
+	_ services.GameRepository = &InMemoryContainer{}
+	_ services.GameRepository = &MongoDBContainer{}
+	_ services.GameRepository = &SQLiteContainer{}
+	_ services.GameRepository = &FileContainer{}
+)
+
+type testContainer struct {
+	name      string
+	container services.GameRepository
+}
+
+// testContainers returns an empty container of every backend that runs without a server,
+// the tests of the containers run against each of them
+func testContainers(t *testing.T) []testContainer {
+
+	dir, err := ioutil.TempDir("", "dao")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqliteContainer, err := CreateSQLiteContainer(filepath.Join(dir, "games.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A short compaction period, so the tests also go through the snapshots
+	fileContainer, err := CreateFileContainer(filepath.Join(dir, "files"), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sqliteContainer.Close()
+		fileContainer.Close()
+		os.RemoveAll(dir)
+	})
+
+	return []testContainer{
+		{name: "MEMORY", container: CreateInMemoryContainer()},
+		{name: "SQLITE", container: sqliteContainer},
+		{name: "FILE", container: fileContainer},
+	}
+}
+
 func TestGameUpsert(t *testing.T) {
 	cases := []struct {
 		name           string
@@ -63,18 +112,271 @@ func TestGameUpsert(t *testing.T) {
 		},
 	}
 
-	container := CreateInMemoryContainer()
-	for _, c := range cases {
-		t.Run(c.name, func(t *testing.T) {
-			container.Insert(c.userGame)
-			gameFromContainer, _ := container.Get(c.userID)
-			if c.name == "OK/INSERT" || c.name == "OK/UPDATE" {
-				assert.Equal(t, gameFromContainer.UserID, c.userGame.UserID)
-				assert.Equal(t, gameFromContainer.Games[0].GameID, c.userGame.Games[0].GameID)
-				assert.Equal(t, gameFromContainer.Games[0].Status, c.expectedStatus)
-			} else if c.name == "FAIL/INSERT_WRONG_USER" && gameFromContainer != nil {
-				t.Errorf("FAIL/INSERT_WRONG_USER should get nil response")
-			}
-		})
+	for _, tc := range testContainers(t) {
+		container := tc.container
+		for _, c := range cases {
+			t.Run(tc.name+"/"+c.name, func(t *testing.T) {
+				container.Insert(c.userGame)
+				gameFromContainer, _ := container.Get(c.userID)
+				if c.name == "OK/INSERT" || c.name == "OK/UPDATE" {
+					assert.Equal(t, gameFromContainer.UserID, c.userGame.UserID)
+					assert.Equal(t, gameFromContainer.Games[0].GameID, c.userGame.Games[0].GameID)
+					assert.Equal(t, gameFromContainer.Games[0].Status, c.expectedStatus)
+				} else if c.name == "FAIL/INSERT_WRONG_USER" && gameFromContainer != nil {
+					t.Errorf("FAIL/INSERT_WRONG_USER should get nil response")
+				}
+			})
+		}
+	}
+}
+
+func TestGameOptionsPersistence(t *testing.T) {
+	userGame := &domain.UserGame{
+		UserID: "test_user_options",
+		Games: []*domain.Game{
+			{
+				GameID:   1,
+				Rows:     3,
+				Columns:  3,
+				Status:   constants.GameStatusOnGoing,
+				Topology: constants.TopologyHex,
+				Wrap:     true,
+			},
+		},
+	}
+
+	for _, tc := range testContainers(t) {
+		tc.container.Insert(userGame)
+		gameFromContainer, _ := tc.container.Get(userGame.UserID)
+		assert.Equal(t, gameFromContainer.Games[0].Wrap, true, tc.name)
+		assert.Equal(t, gameFromContainer.Games[0].Topology, constants.TopologyHex, tc.name)
 	}
+
+	document, err := bson.Marshal(userGame)
+	assert.Nil(t, err)
+	gameFromDocument := &domain.UserGame{}
+	assert.Nil(t, bson.Unmarshal(document, gameFromDocument))
+	assert.Equal(t, gameFromDocument.Games[0].Wrap, true)
+	assert.Equal(t, gameFromDocument.Games[0].Topology, constants.TopologyHex)
+}
+
+func TestDelete(t *testing.T) {
+	cases := []struct {
+		name            string
+		userID          string
+		gameID          int64
+		expectedDeleted bool
+		expectedGames   int
+	}{
+		{
+			name:            "OK/DELETE_GAME",
+			userID:          "test_user_1",
+			gameID:          1,
+			expectedDeleted: true,
+			expectedGames:   1,
+		},
+		{
+			name:          "FAIL/GAME_NOT_FOUND",
+			userID:        "test_user_1",
+			gameID:        3,
+			expectedGames: 2,
+		},
+		{
+			name:          "FAIL/USER_NOT_FOUND",
+			userID:        "wrong_test_user",
+			gameID:        1,
+			expectedGames: 2,
+		},
+	}
+
+	for _, tc := range testContainers(t) {
+		container := tc.container
+		for _, c := range cases {
+			t.Run(tc.name+"/"+c.name, func(t *testing.T) {
+				container.Insert(&domain.UserGame{
+					UserID: "test_user_1",
+					Games:  []*domain.Game{{GameID: 1}, {GameID: 2}},
+				})
+
+				deleted, err := container.DeleteGame(c.userID, c.gameID)
+				assert.Nil(t, err)
+				assert.Equal(t, deleted, c.expectedDeleted)
+				userGame, _ := container.Get("test_user_1")
+				assert.Equal(t, len(userGame.Games), c.expectedGames)
+			})
+		}
+
+		container.Insert(&domain.UserGame{UserID: "test_user_1", Games: []*domain.Game{{GameID: 1}}})
+		container.Insert(&domain.UserGame{UserID: "test_user_2", Games: []*domain.Game{{GameID: 2}}})
+		deleted, err := container.Delete("test_user_1")
+		assert.Nil(t, err, tc.name)
+		assert.True(t, deleted, tc.name)
+		userGame, _ := container.Get("test_user_1")
+		assert.Nil(t, userGame, tc.name)
+		userGame, _ = container.Get("test_user_2")
+		assert.NotNil(t, userGame, tc.name)
+		deleted, _ = container.Delete("test_user_1")
+		assert.False(t, deleted, tc.name)
+
+		// Deleting the last game of a user deletes the user in every container
+		deleted, _ = container.DeleteGame("test_user_2", 2)
+		assert.True(t, deleted, tc.name)
+		userGame, _ = container.Get("test_user_2")
+		assert.Nil(t, userGame, tc.name)
+		deleted, _ = container.Delete("test_user_2")
+		assert.False(t, deleted, tc.name)
+		games, total, _ := container.Find(domain.GameQuery{UserID: "test_user_2", Page: 1, PageSize: 1})
+		assert.Empty(t, games, tc.name)
+		assert.Equal(t, total, 0, tc.name)
+	}
+}
+
+func TestFindGames(t *testing.T) {
+	start := time.Date(2020, 10, 3, 16, 0, 0, 0, time.UTC)
+	userGames := []*domain.UserGame{{
+		UserID: "test_user_1",
+		Games: []*domain.Game{
+			{GameID: 1, Rows: 9, Columns: 9, Mines: 10, Start: start, Status: constants.GameResultWon, Board: [][]domain.Cell{{{}}}},
+			{GameID: 2, Rows: 16, Columns: 16, Mines: 40, Start: start.Add(time.Hour), Status: constants.GameStatusLost},
+			{GameID: 3, Rows: 9, Columns: 9, Mines: 12, Start: start.Add(2 * time.Hour), Status: constants.GameStatusOnGoing},
+		},
+	}, {
+		UserID: "test_user_2",
+		Games: []*domain.Game{
+			{GameID: 4, Rows: 9, Columns: 9, Mines: 10, Start: start.Add(3 * time.Hour), Status: constants.GameResultWon},
+		},
+	}}
+
+	cases := []struct {
+		name          string
+		query         domain.GameQuery
+		expectedIDs   []int64
+		expectedTotal int
+	}{
+		{
+			name:          "OK/NEWEST_FIRST",
+			query:         domain.GameQuery{Page: 1, PageSize: 10},
+			expectedIDs:   []int64{4, 3, 2, 1},
+			expectedTotal: 4,
+		},
+		{
+			name:          "OK/USER",
+			query:         domain.GameQuery{UserID: "test_user_1", Page: 1, PageSize: 10, Ascending: true},
+			expectedIDs:   []int64{1, 2, 3},
+			expectedTotal: 3,
+		},
+		{
+			name:          "OK/SECOND_PAGE",
+			query:         domain.GameQuery{Page: 2, PageSize: 3},
+			expectedIDs:   []int64{1},
+			expectedTotal: 4,
+		},
+		{
+			name:          "OK/PAGE_OUT_OF_RANGE",
+			query:         domain.GameQuery{Page: 3, PageSize: 3},
+			expectedIDs:   []int64{},
+			expectedTotal: 4,
+		},
+		{
+			name:          "OK/STATUS_AND_SIZE",
+			query:         domain.GameQuery{Statuses: []string{constants.GameResultWon, constants.GameStatusOnGoing}, Rows: 9, Columns: 9, Page: 1, PageSize: 10},
+			expectedIDs:   []int64{4, 3, 1},
+			expectedTotal: 3,
+		},
+		{
+			name:          "OK/DATE_RANGE",
+			query:         domain.GameQuery{From: start.Add(time.Hour), To: start.Add(2 * time.Hour), Page: 1, PageSize: 10},
+			expectedIDs:   []int64{3, 2},
+			expectedTotal: 2,
+		},
+		{
+			name:          "OK/SORT_BY_MINES",
+			query:         domain.GameQuery{SortBy: constants.SortByMines, Ascending: true, Page: 1, PageSize: 10},
+			expectedIDs:   []int64{1, 4, 3, 2},
+			expectedTotal: 4,
+		},
+	}
+
+	for _, tc := range testContainers(t) {
+		container := tc.container
+		for _, userGame := range userGames {
+			container.Insert(userGame)
+		}
+
+		for _, c := range cases {
+			t.Run(tc.name+"/"+c.name, func(t *testing.T) {
+				games, total, err := container.Find(c.query)
+				assert.Nil(t, err)
+				assert.Equal(t, total, c.expectedTotal)
+				ids := make([]int64, 0)
+				for _, game := range games {
+					ids = append(ids, game.GameID)
+				}
+				assert.Equal(t, ids, c.expectedIDs)
+			})
+		}
+
+		games, _, _ := container.Find(domain.GameQuery{UserID: "test_user_1", Page: 1, PageSize: 1, Ascending: true, OmitBoard: true})
+		assert.Nil(t, games[0].Board, tc.name)
+		assert.Equal(t, games[0].UserID, "test_user_1", tc.name)
+		stored, _ := container.Get("test_user_1")
+		assert.NotNil(t, stored.Games[0].Board, tc.name)
+	}
+}
+
+func TestGamesFilter(t *testing.T) {
+	from := time.Date(2020, 10, 3, 16, 0, 0, 0, time.UTC)
+	filter := gamesFilter(domain.GameQuery{
+		UserID:   "test_user_1",
+		Statuses: []string{constants.GameResultWon},
+		Rows:     9,
+		From:     from,
+	})
+	assert.Equal(t, filter, bson.M{
+		"user_id":    "test_user_1",
+		"status":     bson.M{"$in": []string{constants.GameResultWon}},
+		"rows":       9,
+		"start_time": bson.M{"$gte": from},
+	})
+	assert.Equal(t, gamesFilter(domain.GameQuery{}), bson.M{})
+}
+
+func TestFindOptions(t *testing.T) {
+	options := findOptions(domain.GameQuery{SortBy: constants.SortByMines, Page: 3, PageSize: 20, OmitBoard: true})
+	assert.Equal(t, options.Sort, bson.D{{Key: constants.SortByMines, Value: -1}, {Key: "game_id", Value: -1}})
+	assert.Equal(t, *options.Skip, int64(40))
+	assert.Equal(t, *options.Limit, int64(20))
+	assert.Equal(t, options.Projection, bson.M{"board": 0, "board_3d": 0, "mine_locations": 0})
+
+	options = findOptions(domain.GameQuery{Page: 1, PageSize: 20, Ascending: true})
+	assert.Equal(t, options.Sort, bson.D{{Key: constants.SortByStartTime, Value: 1}, {Key: "game_id", Value: 1}})
+	assert.Nil(t, options.Projection)
+}
+
+func TestInsertAndUpdateGame(t *testing.T) {
+	for _, tc := range testContainers(t) {
+		container := tc.container
+		container.InsertGame("test_user_1", &domain.Game{GameID: 1, Status: constants.GameStatusCreated})
+		container.InsertGame("test_user_1", &domain.Game{GameID: 2, Status: constants.GameStatusCreated})
+		container.InsertGame("test_user_2", &domain.Game{GameID: 3, Status: constants.GameStatusCreated})
+
+		container.UpdateGame("test_user_1", &domain.Game{GameID: 2, Status: constants.GameStatusOnGoing})
+		container.UpdateGame("test_user_2", &domain.Game{GameID: 1, Status: constants.GameResultWon})
+
+		userGame, _ := container.Get("test_user_1")
+		assert.Equal(t, len(userGame.Games), 2, tc.name)
+		assert.Equal(t, userGame.Games[0].Status, constants.GameStatusCreated, tc.name)
+		assert.Equal(t, userGame.Games[1].Status, constants.GameStatusOnGoing, tc.name)
+		userGame, _ = container.Get("test_user_2")
+		assert.Equal(t, len(userGame.Games), 1, tc.name)
+		assert.Equal(t, userGame.Games[0].Status, constants.GameStatusCreated, tc.name)
+	}
+
+	game := &domain.Game{GameID: 1, Status: constants.GameStatusCreated}
+	document, err := bson.Marshal(gameDocument("test_user_1", game))
+	assert.Nil(t, err)
+	gameFromDocument := &domain.Game{}
+	assert.Nil(t, bson.Unmarshal(document, gameFromDocument))
+	assert.Equal(t, gameFromDocument.UserID, "test_user_1")
+	assert.Equal(t, game.UserID, "")
 }
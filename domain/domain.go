@@ -1,6 +1,10 @@
 package domain
 
-import "time"
+import (
+	"time"
+
+	"github.com/mercadolibre/minesweeper/constants"
+)
 
 // Cell ..
 type Cell struct {
@@ -8,19 +12,69 @@ type Cell struct {
 	HasMine       bool   `json:"has_mine" bson:"has_mine"`
 	SourroundedBy int    `json:"sourrounded_by" bson:"sourrounded_by"`
 	Flag          string `json:"flag" bson:"flag"`
+	Exploded      bool   `json:"exploded" bson:"exploded"`
+}
+
+// CellLocation is the position of a cell, the layer is always 0 in flat boards
+type CellLocation struct {
+	Layer  int `json:"layer" bson:"layer"`
+	Column int `json:"column" bson:"column"`
+	Row    int `json:"row" bson:"row"`
 }
 
-// Game models tha minesweeper game properties
+// Move is an entry of the move log of a game. Undo moves cancel the last move
+// that was not undone yet, they don't carry a cell
+type Move struct {
+	Number int       `json:"number" bson:"number"`
+	Action string    `json:"action" bson:"action"`
+	Layer  int       `json:"layer" bson:"layer"`
+	Column int       `json:"column" bson:"column"`
+	Row    int       `json:"row" bson:"row"`
+	Flag   string    `json:"flag,omitempty" bson:"flag,omitempty"`
+	Time   time.Time `json:"time" bson:"time"`
+}
+
+// Game models tha minesweeper game properties.
+// The board is indexed as Board[column][row], with the column going from left
+// to right and the row from top to bottom, the same convention used by the
+// reveal and flag requests. In hexagonal boards the odd rows are shifted half
+// a cell to the right. Wrapping boards join their opposite edges.
+// Three dimensional games leave Board empty and use Board3D[layer][column][row].
+// ActiveTime is the time played until the last pause or the end of the game, the
+// current stretch of play started at ResumedAt.
+// MineLocations keeps the mines as they were before the first move, or right after
+// the first reveal settled them, so the board can be rebuilt replaying the moves
 type Game struct {
-	GameID        int64     `json:"game_id" bson:"game_id"`
-	Rows          int       `json:"rows" bson:"rows"`
-	Columns       int       `json:"columns" bson:"columns"`
-	Mines         int       `json:"mines" bson:"mines"`
-	Start         time.Time `json:"start_time" bson:"start_time"`
-	Finish        time.Time `json:"finish_time" bson:"finish_time"`
-	CellsRevealed int       `json:"cells_revealed" bson:"cells_revealed"`
-	Status        string    `json:"status" bson:"status"`
-	Board         [][]Cell  `json:"board" bson:"board"`
+	GameID         int64          `json:"game_id" bson:"game_id"`
+	UserID         string         `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	Rows           int            `json:"rows" bson:"rows"`
+	Columns        int            `json:"columns" bson:"columns"`
+	Mines          int            `json:"mines" bson:"mines"`
+	Start          time.Time      `json:"start_time" bson:"start_time"`
+	Finish         time.Time      `json:"finish_time" bson:"finish_time"`
+	CellsRevealed  int            `json:"cells_revealed" bson:"cells_revealed"`
+	Status         string         `json:"status" bson:"status"`
+	Board          [][]Cell       `json:"board" bson:"board"`
+	FirstClick     string         `json:"first_click" bson:"first_click"`
+	FirstClickDone bool           `json:"first_click_done" bson:"first_click_done"`
+	Preset         string         `json:"preset" bson:"preset"`
+	NoGuess        bool           `json:"no_guess" bson:"no_guess"`
+	Solvable       bool           `json:"guaranteed_solvable" bson:"guaranteed_solvable"`
+	Seed           int64          `json:"seed" bson:"seed"`
+	Ranked         bool           `json:"ranked" bson:"ranked"`
+	Topology       string         `json:"topology" bson:"topology"`
+	Wrap           bool           `json:"wrap" bson:"wrap"`
+	Layers         int            `json:"layers,omitempty" bson:"layers,omitempty"`
+	Board3D        [][][]Cell     `json:"board_3d,omitempty" bson:"board_3d,omitempty"`
+	Lives          int            `json:"lives" bson:"lives"`
+	Explosions     int            `json:"explosions" bson:"explosions"`
+	MaxLives       int            `json:"max_lives" bson:"max_lives"`
+	UndoEnabled    bool           `json:"undo_enabled" bson:"undo_enabled"`
+	Moves          []Move         `json:"moves" bson:"moves"`
+	MineLocations  []CellLocation `json:"mine_locations,omitempty" bson:"mine_locations,omitempty"`
+	ActiveTime     time.Duration  `json:"active_time" bson:"active_time"`
+	ResumedAt      time.Time      `json:"resumed_at" bson:"resumed_at"`
+	TimeLimit      int            `json:"time_limit_seconds" bson:"time_limit_seconds"`
 }
 
 // UserGame models wich games owns wich user
@@ -31,7 +85,25 @@ type UserGame struct {
 
 // NewGameConditionsRequest ...
 type NewGameConditionsRequest struct {
-	UserID  string `json:"user_id"`
+	UserID     string `json:"user_id"`
+	Rows       int    `json:"rows"`
+	Columns    int    `json:"columns"`
+	Mines      int    `json:"mines"`
+	FirstClick string `json:"first_click"`
+	Preset     string `json:"preset"`
+	NoGuess    bool   `json:"no_guess"`
+	Seed       *int64 `json:"seed"`
+	Ranked     bool   `json:"ranked"`
+	Topology   string `json:"topology"`
+	Wrap       bool   `json:"wrap"`
+	Layers     int    `json:"layers"`
+	Lives      int    `json:"lives"`
+	TimeLimit  int    `json:"time_limit_seconds"`
+}
+
+// Preset is a named board configuration that can be requested instead of the dimensions
+type Preset struct {
+	Name    string `json:"name"`
 	Rows    int    `json:"rows"`
 	Columns int    `json:"columns"`
 	Mines   int    `json:"mines"`
@@ -41,6 +113,7 @@ type NewGameConditionsRequest struct {
 type FlagCellRequest struct {
 	UserID string `json:"user_id"`
 	GameID int64  `json:"game_id"`
+	Layer  int    `json:"layer"`
 	Row    int    `json:"row"`
 	Column int    `json:"column"`
 	Flag   string `json:"flag"`
@@ -50,6 +123,279 @@ type FlagCellRequest struct {
 type RevealCellRequest struct {
 	UserID string `json:"user_id"`
 	GameID int64  `json:"game_id"`
+	Layer  int    `json:"layer"`
 	Row    int    `json:"row"`
 	Column int    `json:"column"`
 }
+
+// PlayerCell is the view of a cell that can be sent to the player,
+// mine data is only present once the cell is revealed
+type PlayerCell struct {
+	IsRevealed    bool   `json:"is_revealed"`
+	HasMine       *bool  `json:"has_mine,omitempty"`
+	SourroundedBy *int   `json:"sourrounded_by,omitempty"`
+	Flag          string `json:"flag"`
+	Exploded      bool   `json:"exploded"`
+}
+
+// PlayerGame is the view of a game that can be sent to the player
+type PlayerGame struct {
+	GameID         int64            `json:"game_id"`
+	Rows           int              `json:"rows"`
+	Columns        int              `json:"columns"`
+	Mines          int              `json:"mines"`
+	Start          time.Time        `json:"start_time"`
+	Finish         time.Time        `json:"finish_time"`
+	CellsRevealed  int              `json:"cells_revealed"`
+	Status         string           `json:"status"`
+	Board          [][]PlayerCell   `json:"board"`
+	FirstClick     string           `json:"first_click"`
+	Preset         string           `json:"preset"`
+	NoGuess        bool             `json:"no_guess"`
+	Solvable       bool             `json:"guaranteed_solvable"`
+	Seed           *int64           `json:"seed,omitempty"`
+	Ranked         bool             `json:"ranked"`
+	Topology       string           `json:"topology"`
+	Wrap           bool             `json:"wrap"`
+	Layers         int              `json:"layers,omitempty"`
+	Board3D        [][][]PlayerCell `json:"board_3d,omitempty"`
+	Lives          int              `json:"lives"`
+	Explosions     int              `json:"explosions"`
+	MaxLives       int              `json:"max_lives"`
+	UndoEnabled    bool             `json:"undo_enabled"`
+	Moves          []Move           `json:"moves"`
+	ElapsedSeconds int64            `json:"elapsed_seconds"`
+	TimeLimit      int              `json:"time_limit_seconds"`
+}
+
+// PlayerUserGame is the view of the games of a user that can be sent to the player,
+// listings also send the page and the number of games matching the query
+type PlayerUserGame struct {
+	Games    []*PlayerGame `json:"games"`
+	UserID   string        `json:"user_id"`
+	Page     int           `json:"page,omitempty"`
+	PageSize int           `json:"page_size,omitempty"`
+	Total    int           `json:"total,omitempty"`
+}
+
+// GameQuery selects a page of games sorted by SortBy. Empty filters match every game,
+// From and To bound the start time of the games. OmitBoard leaves out the boards and
+// the mine locations of summary listings
+type GameQuery struct {
+	UserID    string
+	Statuses  []string
+	Rows      int
+	Columns   int
+	From      time.Time
+	To        time.Time
+	SortBy    string
+	Ascending bool
+	Page      int
+	PageSize  int
+	OmitBoard bool
+}
+
+// GamePage is a page of the games matching a query, Total counts every matching game
+type GamePage struct {
+	Games    []*Game `json:"games"`
+	Page     int     `json:"page"`
+	PageSize int     `json:"page_size"`
+	Total    int     `json:"total"`
+}
+
+// GameReplay is the board of a finished game after one of its moves, Move is the
+// number of moves of the log that were applied and Moves is the whole log
+type GameReplay struct {
+	GameID     int64            `json:"game_id"`
+	Move       int              `json:"move"`
+	TotalMoves int              `json:"total_moves"`
+	Start      time.Time        `json:"start_time"`
+	Finish     time.Time        `json:"finish_time"`
+	Status     string           `json:"status"`
+	Lives      int              `json:"lives"`
+	Explosions int              `json:"explosions"`
+	Board      [][]PlayerCell   `json:"board"`
+	Board3D    [][][]PlayerCell `json:"board_3d,omitempty"`
+	Moves      []Move           `json:"moves"`
+}
+
+// UserStats sums up the games of a user. Abandoned and timed out games are counted
+// apart from the lost ones, BestTimeSeconds is the shortest won game
+type UserStats struct {
+	UserID             string `json:"user_id"`
+	GamesPlayed        int    `json:"games_played"`
+	Won                int    `json:"won"`
+	Lost               int    `json:"lost"`
+	Abandoned          int    `json:"abandoned"`
+	TimedOut           int    `json:"timed_out"`
+	InProgress         int    `json:"in_progress"`
+	BestTimeSeconds    *int64 `json:"best_time_seconds,omitempty"`
+	TotalPlayedSeconds int64  `json:"total_played_seconds"`
+}
+
+// IsOver tells if the game can no longer be played
+func (g *Game) IsOver() bool {
+	return g.Status == constants.GameResultWon || g.Status == constants.GameStatusLost ||
+		g.Status == constants.GameStatusTimedOut || g.Status == constants.GameStatusAbandoned
+}
+
+// Deadline returns the moment the time limit of the game runs out, counted from
+// its start, and false if the game has no time limit
+func (g *Game) Deadline() (time.Time, bool) {
+	if g.TimeLimit <= 0 {
+		return time.Time{}, false
+	}
+	return g.Start.Add(time.Duration(g.TimeLimit) * time.Second), true
+}
+
+// Elapsed returns the time the game has been played, leaving out the pauses.
+// Games finished before the active time was tracked count from Start to Finish
+func (g *Game) Elapsed(now time.Time) time.Duration {
+
+	if g.Status != constants.GameStatusOnGoing {
+		if g.ActiveTime == 0 && !g.Finish.IsZero() {
+			return g.Finish.Sub(g.Start)
+		}
+		return g.ActiveTime
+	}
+	resumedAt := g.ResumedAt
+	if resumedAt.IsZero() {
+		resumedAt = g.Start
+	}
+	return g.ActiveTime + now.Sub(resumedAt)
+}
+
+// PlayerView builds the view of the game that can be sent to the player at the given time.
+// Mines and neighbour counts of unrevealed cells, and the seed that generated
+// them, are hidden until the game is over
+func (g *Game) PlayerView(now time.Time) *PlayerGame {
+
+	showAll := g.IsOver()
+	board, board3D := g.boardPlayerView(showAll)
+
+	view := &PlayerGame{
+		GameID:         g.GameID,
+		Rows:           g.Rows,
+		Columns:        g.Columns,
+		Mines:          g.Mines,
+		Start:          g.Start,
+		Finish:         g.Finish,
+		CellsRevealed:  g.CellsRevealed,
+		Status:         g.Status,
+		Board:          board,
+		FirstClick:     g.FirstClick,
+		Preset:         g.Preset,
+		NoGuess:        g.NoGuess,
+		Solvable:       g.Solvable,
+		Ranked:         g.Ranked,
+		Topology:       g.Topology,
+		Wrap:           g.Wrap,
+		Layers:         g.Layers,
+		Board3D:        board3D,
+		Lives:          g.Lives,
+		Explosions:     g.Explosions,
+		MaxLives:       g.MaxLives,
+		UndoEnabled:    g.UndoEnabled,
+		Moves:          g.Moves,
+		ElapsedSeconds: int64(g.Elapsed(now).Seconds()),
+		TimeLimit:      g.TimeLimit,
+	}
+	if showAll && !g.Ranked {
+		seed := g.Seed
+		view.Seed = &seed
+	}
+	return view
+}
+
+// ReplayView builds the replay of a finished game from the game rebuilt after the
+// given move, the whole board is shown as the game is already over
+func (g *Game) ReplayView(move int, moves []Move) *GameReplay {
+
+	board, board3D := g.boardPlayerView(true)
+	return &GameReplay{
+		GameID:     g.GameID,
+		Move:       move,
+		TotalMoves: len(moves),
+		Start:      g.Start,
+		Finish:     g.Finish,
+		Status:     g.Status,
+		Lives:      g.Lives,
+		Explosions: g.Explosions,
+		Board:      board,
+		Board3D:    board3D,
+		Moves:      moves,
+	}
+}
+
+// PlayerView builds the view of the user games that can be sent to the player
+func (ug *UserGame) PlayerView(now time.Time) *PlayerUserGame {
+
+	games := make([]*PlayerGame, 0, len(ug.Games))
+	for _, game := range ug.Games {
+		games = append(games, game.PlayerView(now))
+	}
+	return &PlayerUserGame{
+		Games:  games,
+		UserID: ug.UserID,
+	}
+}
+
+// PlayerView builds the view of a page of the games of a user that can be sent to the player
+func (gp *GamePage) PlayerView(userID string, now time.Time) *PlayerUserGame {
+
+	games := make([]*PlayerGame, 0, len(gp.Games))
+	for _, game := range gp.Games {
+		games = append(games, game.PlayerView(now))
+	}
+	return &PlayerUserGame{
+		Games:    games,
+		UserID:   userID,
+		Page:     gp.Page,
+		PageSize: gp.PageSize,
+		Total:    gp.Total,
+	}
+}
+
+func (g *Game) boardPlayerView(showAll bool) ([][]PlayerCell, [][][]PlayerCell) {
+
+	var board [][]PlayerCell
+	if g.Board != nil {
+		board = layerPlayerView(g.Board, showAll)
+	}
+	var board3D [][][]PlayerCell
+	if g.Board3D != nil {
+		board3D = make([][][]PlayerCell, len(g.Board3D))
+		for layer := range g.Board3D {
+			board3D[layer] = layerPlayerView(g.Board3D[layer], showAll)
+		}
+	}
+	return board, board3D
+}
+
+func layerPlayerView(layer [][]Cell, showAll bool) [][]PlayerCell {
+
+	view := make([][]PlayerCell, len(layer))
+	for i := range layer {
+		view[i] = make([]PlayerCell, len(layer[i]))
+		for j, cell := range layer[i] {
+			view[i][j] = cell.playerView(showAll)
+		}
+	}
+	return view
+}
+
+func (c Cell) playerView(showAll bool) PlayerCell {
+
+	view := PlayerCell{
+		IsRevealed: c.IsRevealed,
+		Flag:       c.Flag,
+		Exploded:   c.Exploded,
+	}
+	if c.IsRevealed || showAll {
+		hasMine := c.HasMine
+		sourroundedBy := c.SourroundedBy
+		view.HasMine = &hasMine
+		view.SourroundedBy = &sourroundedBy
+	}
+	return view
+}
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mercadolibre/minesweeper/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlayerView(t *testing.T) {
+	cases := []struct {
+		name           string
+		status         string
+		expectedHidden bool
+	}{
+		{
+			name:           "OK/ON_GOING_HIDES_MINES",
+			status:         constants.GameStatusOnGoing,
+			expectedHidden: true,
+		},
+		{
+			name:           "OK/LOST_SHOWS_MINES",
+			status:         constants.GameStatusLost,
+			expectedHidden: false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			game := &Game{
+				GameID:  1,
+				Rows:    1,
+				Columns: 2,
+				Mines:   1,
+				Status:  c.status,
+				Board: [][]Cell{
+					{
+						Cell{HasMine: true, SourroundedBy: 0, Flag: constants.FlagRedFlag},
+					},
+					{
+						Cell{HasMine: false, SourroundedBy: 1, IsRevealed: true},
+					},
+				},
+			}
+
+			view := game.PlayerView(time.Now())
+
+			assert.Equal(t, view.Board[0][0].Flag, constants.FlagRedFlag)
+			assert.Equal(t, view.Board[0][0].HasMine == nil, c.expectedHidden)
+			assert.Equal(t, view.Board[0][0].SourroundedBy == nil, c.expectedHidden)
+			assert.Equal(t, *view.Board[1][0].HasMine, false)
+			assert.Equal(t, *view.Board[1][0].SourroundedBy, 1)
+		})
+	}
+}
+func TestElapsed(t *testing.T) {
+	start := time.Date(2020, 10, 3, 16, 0, 0, 0, time.UTC)
+	now := start.Add(time.Hour)
+	cases := []struct {
+		name            string
+		game            *Game
+		expectedElapsed time.Duration
+	}{
+		{
+			name: "OK/ON_GOING",
+			game: &Game{
+				Status:     constants.GameStatusOnGoing,
+				Start:      start,
+				ActiveTime: 10 * time.Second,
+				ResumedAt:  now.Add(-5 * time.Second),
+			},
+			expectedElapsed: 15 * time.Second,
+		},
+		{
+			name: "OK/PAUSED",
+			game: &Game{
+				Status:     constants.GameStatusPaused,
+				Start:      start,
+				ActiveTime: 10 * time.Second,
+				ResumedAt:  start,
+			},
+			expectedElapsed: 10 * time.Second,
+		},
+		{
+			name: "OK/FINISHED",
+			game: &Game{
+				Status:     constants.GameResultWon,
+				Start:      start,
+				Finish:     start.Add(time.Minute),
+				ActiveTime: 30 * time.Second,
+			},
+			expectedElapsed: 30 * time.Second,
+		},
+		{
+			name: "OK/LEGACY_ON_GOING",
+			game: &Game{
+				Status: constants.GameStatusOnGoing,
+				Start:  start,
+			},
+			expectedElapsed: time.Hour,
+		},
+		{
+			name: "OK/LEGACY_FINISHED",
+			game: &Game{
+				Status: constants.GameStatusLost,
+				Start:  start,
+				Finish: start.Add(time.Minute),
+			},
+			expectedElapsed: time.Minute,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.game.Elapsed(now), c.expectedElapsed)
+		})
+	}
+}
@@ -4,6 +4,7 @@ go 1.14
 
 require (
 	github.com/gin-gonic/gin v1.6.3
-	go.mongodb.org/mongo-driver v1.4.1
+	github.com/mattn/go-sqlite3 v1.14.4
 	github.com/stretchr/testify v1.4.0
+	go.mongodb.org/mongo-driver v1.4.1
 )
@@ -2,17 +2,26 @@ package main
 
 import (
 	"fmt"
-	"os"
+	"log"
+	"net/http"
+	"time"
 
+	"github.com/mercadolibre/minesweeper/config"
 	"github.com/mercadolibre/minesweeper/server"
 )
 
 func main() {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "5000"
-		fmt.Println("Fixed port to 5000")
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("invalid configuration: " + err.Error())
 	}
-	fmt.Println("Listening port: " + port)
-	server.New().Run(":" + port)
+
+	httpServer := &http.Server{
+		Addr:         ":" + cfg.Port,
+		Handler:      server.New(cfg),
+		ReadTimeout:  time.Duration(cfg.Server.ReadTimeoutSeconds) * time.Second,
+		WriteTimeout: time.Duration(cfg.Server.WriteTimeoutSeconds) * time.Second,
+	}
+	fmt.Println("Listening port: " + cfg.Port)
+	log.Fatal(httpServer.ListenAndServe())
 }
@@ -1,9 +1,16 @@
 package middlewares
 
 import (
+	"crypto/subtle"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+	"github.com/mercadolibre/minesweeper/errors"
 )
 
+// AdminTokenHeader is the header that carries the token of privileged requests
+const AdminTokenHeader = "X-Admin-Token"
+
 // AdaptHandler ...
 func AdaptHandler(handler func(c *gin.Context) error) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -16,3 +23,19 @@ func AdaptHandler(handler func(c *gin.Context) error) gin.HandlerFunc {
 		}
 	}
 }
+
+// RequireAdmin only lets through the requests carrying the admin token.
+// If no token is configured every privileged request is rejected
+func RequireAdmin(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		received := c.GetHeader(AdminTokenHeader)
+		if token == "" || subtle.ConstantTimeCompare([]byte(received), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusForbidden, &errors.ApiError{
+				Message:  "this endpoint requires a valid " + AdminTokenHeader + " header",
+				ErrorStr: "forbidden",
+				Status:   http.StatusForbidden,
+			})
+		}
+	}
+}
@@ -4,13 +4,18 @@ import (
 	"fmt"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mercadolibre/minesweeper/config"
+	"github.com/mercadolibre/minesweeper/controllers"
 )
 
-func bootstrap(router *gin.Engine) {
+func bootstrap(router *gin.Engine, cfg *config.Config) {
 	fmt.Println("Bootstrap - Starting app...")
 
-	application := resolveGameController()
-	mapUrlsToControllers(router, application)
+	application := resolveGameController(cfg)
+	if cfg.AdminToken == "" && cfg.LogLevel != config.LogLevelError {
+		fmt.Println("Bootstrap - MINESWEEPER_ADMIN_TOKEN is not set, privileged endpoints are disabled")
+	}
+	mapUrlsToControllers(router, application, &controllers.ConfigController{Config: cfg}, cfg.AdminToken)
 
 	fmt.Println("Bootstrap - Application is up")
 }
@@ -1,17 +1,53 @@
 package server
 
 import (
+	"log"
+	"time"
+
+	"github.com/mercadolibre/minesweeper/config"
 	"github.com/mercadolibre/minesweeper/controllers"
 	"github.com/mercadolibre/minesweeper/dao"
 	"github.com/mercadolibre/minesweeper/services"
 )
 
-func resolveGameController() *controllers.GameController {
+func resolveGameController(cfg *config.Config) *controllers.GameController {
 
 	return &controllers.GameController{
 		GameService: &services.GameService{
-			InMemoryContainer: *dao.CreateInMemoryContainer(),
-			Container:         dao.CreateContainer(),
+			Repository:      resolveGameRepository(cfg.Storage),
+			CustomPresets:   cfg.Presets,
+			UndoRankedGames: cfg.RankedUndo,
 		},
+		Limits: cfg.Limits,
+	}
+}
+
+// resolveGameRepository creates the store of the configured backend, "memory" keeps the
+// games in the process, "mongodb" in a MongoDB collection, "sqlite" in a SQLite file and
+// "file" in memory, journaled to a directory
+func resolveGameRepository(storage config.Storage) services.GameRepository {
+
+	switch storage.Backend {
+	case config.StorageMongoDB:
+		container, err := dao.CreateContainer(storage.MongoURI, storage.Database, storage.Collection,
+			time.Duration(storage.TimeoutSeconds)*time.Second)
+		if err != nil {
+			log.Fatal("connecting to MongoDB: " + err.Error())
+		}
+		return container
+	case config.StorageSQLite:
+		container, err := dao.CreateSQLiteContainer(storage.SQLitePath)
+		if err != nil {
+			log.Fatal("opening the SQLite database: " + err.Error())
+		}
+		return container
+	case config.StorageFile:
+		container, err := dao.CreateFileContainer(storage.FileDir, storage.SnapshotEvery)
+		if err != nil {
+			log.Fatal("restoring the games of " + storage.FileDir + ": " + err.Error())
+		}
+		return container
+	default:
+		return dao.CreateInMemoryContainer()
 	}
 }
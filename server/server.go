@@ -1,14 +1,25 @@
 package server
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/mercadolibre/minesweeper/config"
+)
 
-// New creates a new router
-func New() *gin.Engine {
+// New creates a new router. The debug log level runs gin in debug mode, and the
+// requests are only logged down to the info level
+func New(cfg *config.Config) *gin.Engine {
 
 	gin.SetMode(gin.ReleaseMode)
-	router := gin.Default()
+	if cfg.LogLevel == config.LogLevelDebug {
+		gin.SetMode(gin.DebugMode)
+	}
+	router := gin.New()
+	if cfg.LogLevel == config.LogLevelDebug || cfg.LogLevel == config.LogLevelInfo {
+		router.Use(gin.Logger())
+	}
+	router.Use(gin.Recovery())
 
-	bootstrap(router)
+	bootstrap(router, cfg)
 
 	return router
 }
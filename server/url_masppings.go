@@ -7,20 +7,36 @@ import (
 )
 
 // Associate URLs with controllers
-func mapUrlsToControllers(router *gin.Engine, gameController *controllers.GameController) {
+func mapUrlsToControllers(router *gin.Engine, gameController *controllers.GameController, configController *controllers.ConfigController, adminToken string) {
 	router.GET("/ping", gameController.Pong)
 
+	router.GET("minesweeper/presets",
+		middlewares.AdaptHandler(gameController.GetPresets),
+	)
+
 	router.GET("minesweeper/users/:user_id/games",
-		middlewares.AdaptHandler(gameController.ValidateGetGamesByUserID),
+		middlewares.AdaptHandler(gameController.ValidateListUserGames),
 		middlewares.AdaptHandler(gameController.GetGamesByUserID),
 	)
 
+	router.GET("minesweeper/users/:user_id/stats",
+		middlewares.AdaptHandler(gameController.ValidateGetGamesByUserID),
+		middlewares.AdaptHandler(gameController.GetUserStats),
+	)
+
 	router.GET("minesweeper/users/:user_id/games/:game_id",
 		middlewares.AdaptHandler(gameController.ValidateGetGameByGameID),
 		middlewares.AdaptHandler(gameController.GetGameByGameID),
 	)
 
+	router.GET("minesweeper/users/:user_id/games/:game_id/board",
+		middlewares.RequireAdmin(adminToken),
+		middlewares.AdaptHandler(gameController.ValidateGetGameByGameID),
+		middlewares.AdaptHandler(gameController.GetFullGameByGameID),
+	)
+
 	router.GET("minesweeper/users/:user_id/games/:game_id/solution",
+		middlewares.RequireAdmin(adminToken),
 		middlewares.AdaptHandler(gameController.ValidateGetGameByGameID),
 		middlewares.AdaptHandler(gameController.ShowSolution),
 	)
@@ -30,6 +46,11 @@ func mapUrlsToControllers(router *gin.Engine, gameController *controllers.GameCo
 		middlewares.AdaptHandler(gameController.ShowStatus),
 	)
 
+	router.GET("minesweeper/users/:user_id/games/:game_id/replay",
+		middlewares.AdaptHandler(gameController.ValidateReplay),
+		middlewares.AdaptHandler(gameController.GetReplay),
+	)
+
 	router.POST("minesweeper/users/:user_id/games",
 		middlewares.AdaptHandler(gameController.ValidatePost),
 		middlewares.AdaptHandler(gameController.CreateNewGame),
@@ -45,11 +66,54 @@ func mapUrlsToControllers(router *gin.Engine, gameController *controllers.GameCo
 		middlewares.AdaptHandler(gameController.RevealCell),
 	)
 
+	router.POST("minesweeper/users/:user_id/games/:game_id/chord",
+		middlewares.AdaptHandler(gameController.ValidateReveal),
+		middlewares.AdaptHandler(gameController.ChordCell),
+	)
+
+	router.POST("minesweeper/users/:user_id/games/:game_id/undo",
+		middlewares.AdaptHandler(gameController.ValidateGetGameByGameID),
+		middlewares.AdaptHandler(gameController.UndoMove),
+	)
+
+	router.POST("minesweeper/users/:user_id/games/:game_id/pause",
+		middlewares.AdaptHandler(gameController.ValidateGetGameByGameID),
+		middlewares.AdaptHandler(gameController.PauseGame),
+	)
+
+	router.POST("minesweeper/users/:user_id/games/:game_id/resume",
+		middlewares.AdaptHandler(gameController.ValidateGetGameByGameID),
+		middlewares.AdaptHandler(gameController.ResumeGame),
+	)
+
+	router.POST("minesweeper/users/:user_id/games/:game_id/abandon",
+		middlewares.AdaptHandler(gameController.ValidateGetGameByGameID),
+		middlewares.AdaptHandler(gameController.AbandonGame),
+	)
+
+	router.DELETE("minesweeper/users/:user_id/games/:game_id",
+		middlewares.AdaptHandler(gameController.ValidateGetGameByGameID),
+		middlewares.AdaptHandler(gameController.DeleteGame),
+	)
+
+	router.DELETE("minesweeper/users/:user_id/games",
+		middlewares.AdaptHandler(gameController.ValidateGetGamesByUserID),
+		middlewares.AdaptHandler(gameController.DeleteUserGames),
+	)
+
 	router.DELETE("minesweeper/games",
+		middlewares.RequireAdmin(adminToken),
 		middlewares.AdaptHandler(gameController.DeleteAllGames),
 	)
 
 	router.GET("minesweeper/games",
+		middlewares.RequireAdmin(adminToken),
+		middlewares.AdaptHandler(gameController.ValidateListAllGames),
 		middlewares.AdaptHandler(gameController.GetllGames),
 	)
+
+	router.GET("minesweeper/config",
+		middlewares.RequireAdmin(adminToken),
+		middlewares.AdaptHandler(configController.GetConfig),
+	)
 }
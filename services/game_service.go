@@ -1,37 +1,184 @@
 package services
 
 import (
-	"math/rand"
+	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/mercadolibre/minesweeper/constants"
-	"github.com/mercadolibre/minesweeper/dao"
 	"github.com/mercadolibre/minesweeper/domain"
 	"github.com/mercadolibre/minesweeper/errors"
 )
 
 // GameService ...
 type GameService struct {
-	Container         dao.MongoDBContainer
-	InMemoryContainer dao.InMemoryContainer
+	Repository    GameRepository
+	CustomPresets []*domain.Preset
+	// NewRandomSource and NewRankedRandomSource create the random source of every
+	// casual and ranked board, seeded math/rand and crypto/rand when nil
+	NewRandomSource       RandomSourceFactory
+	NewRankedRandomSource RandomSourceFactory
+	// UndoRankedGames lets ranked games undo their moves, casual games always can
+	UndoRankedGames bool
+	// Clock returns the current time, time.Now when nil
+	Clock func() time.Time
+}
+
+var defaultPresets = []*domain.Preset{
+	{Name: constants.PresetBeginner, Rows: 9, Columns: 9, Mines: 10},
+	{Name: constants.PresetIntermediate, Rows: 16, Columns: 16, Mines: 40},
+	{Name: constants.PresetExpert, Rows: 16, Columns: 30, Mines: 99},
+}
+
+// GetPresets returns the default presets followed by the custom ones
+func (gs *GameService) GetPresets() []*domain.Preset {
+	presets := make([]*domain.Preset, 0, len(defaultPresets)+len(gs.CustomPresets))
+	presets = append(presets, defaultPresets...)
+	return append(presets, gs.CustomPresets...)
+}
+
+// ValidateCustomPresets checks that the custom presets describe valid boards within the
+// given size and that their names don't clash with any other preset
+func ValidateCustomPresets(presets []*domain.Preset, maxRows, maxColumns int) error {
+
+	names := make(map[string]bool)
+	for _, preset := range defaultPresets {
+		names[preset.Name] = true
+	}
+	for _, preset := range presets {
+		if preset.Name == "" {
+			return fmt.Errorf("custom presets must have a name")
+		}
+		if names[preset.Name] {
+			return fmt.Errorf("preset %s is defined twice", preset.Name)
+		}
+		if preset.Rows <= 0 || preset.Rows > maxRows {
+			return fmt.Errorf("preset %s: rows must be greater than 0 and less or equal than %d", preset.Name, maxRows)
+		}
+		if preset.Columns <= 0 || preset.Columns > maxColumns {
+			return fmt.Errorf("preset %s: columns must be greater than 0 and less or equal than %d", preset.Name, maxColumns)
+		}
+		if preset.Mines <= 0 || preset.Mines > preset.Rows*preset.Columns {
+			return fmt.Errorf("preset %s: the number of mines must be at least one, and less or equal than total of cells in the game", preset.Name)
+		}
+		names[preset.Name] = true
+	}
+	return nil
+}
+
+// GetPreset returns the preset with the given name, nil if it doesn't exist
+func (gs *GameService) GetPreset(name string) *domain.Preset {
+	for _, preset := range gs.GetPresets() {
+		if preset.Name == name {
+			return preset
+		}
+	}
+	return nil
 }
 
 // GetGamesByUserID returns all the games by a user
 func (gs *GameService) GetGamesByUserID(userID string) (*domain.UserGame, error) {
-	userGame, err := gs.InMemoryContainer.Get(userID)
+	userGame, err := gs.Repository.Get(userID)
 	if err != nil {
 		return nil, err
 	}
 	if userGame == nil {
 		return nil, nil
 	}
+	err = gs.refreshGames(userGame)
+	if err != nil {
+		return nil, err
+	}
 	return userGame, nil
 }
 
+// ListUserGames returns a page of the games of a user matching the query, nil if the user
+// has no games
+func (gs *GameService) ListUserGames(query domain.GameQuery) (*domain.GamePage, error) {
+
+	page, err := gs.findGames(query)
+	if err != nil {
+		return nil, err
+	}
+	if page.Total == 0 {
+		// No game matches the filters, a single game without its board tells if the user exists
+		_, total, err := gs.Repository.Find(domain.GameQuery{UserID: query.UserID, Page: 1, PageSize: 1, OmitBoard: true})
+		if err != nil {
+			return nil, err
+		}
+		if total == 0 {
+			return nil, nil
+		}
+	}
+
+	err = gs.refreshPage(page)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// ListAllGames returns a page of the games of every user matching the query
+func (gs *GameService) ListAllGames(query domain.GameQuery) (*domain.GamePage, error) {
+
+	page, err := gs.findGames(query)
+	if err != nil {
+		return nil, err
+	}
+	err = gs.refreshPage(page)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// refreshPage refreshes the listed games. They are copies, maybe without their board, so the
+// stored games are refreshed through their users, only when a listed game changed
+func (gs *GameService) refreshPage(page *domain.GamePage) error {
+
+	refreshedUsers := make(map[string]bool)
+	now := gs.Now()
+	for _, game := range page.Games {
+		if refreshGame(game, now) && !refreshedUsers[game.UserID] {
+			refreshedUsers[game.UserID] = true
+			_, err := gs.GetGamesByUserID(game.UserID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// findGames fills the defaults of the query and looks for the page of games in the container
+func (gs *GameService) findGames(query domain.GameQuery) (*domain.GamePage, error) {
+
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PageSize < 1 {
+		query.PageSize = constants.DefaultPageSize
+	}
+	if query.SortBy == "" {
+		query.SortBy = constants.SortByStartTime
+	}
+	query.Statuses = withLegacyStatuses(query.Statuses)
+
+	games, total, err := gs.Repository.Find(query)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.GamePage{
+		Games:    games,
+		Page:     query.Page,
+		PageSize: query.PageSize,
+		Total:    total,
+	}, nil
+}
+
 // GetGameByGameID returns all the games by a user
 func (gs *GameService) GetGameByGameID(userID string, gameID int64) (*domain.Game, error) {
-	userGames, err := gs.InMemoryContainer.Get(userID)
+	userGames, err := gs.GetGamesByUserID(userID)
 	if err != nil {
 		return nil, err
 	}
@@ -51,8 +198,24 @@ func (gs *GameService) GetGameByGameID(userID string, gameID int64) (*domain.Gam
 // CreateGame creates a new game
 func (gs *GameService) CreateGame(gameRequest *domain.NewGameConditionsRequest) (*domain.Game, error) {
 
-	newUser := false
-	if gameRequest.Mines > gameRequest.Columns*gameRequest.Rows {
+	if gameRequest.Preset != "" {
+		preset := gs.GetPreset(gameRequest.Preset)
+		if preset == nil {
+			return nil, &errors.ApiError{
+				Message:  "unknown preset " + gameRequest.Preset,
+				ErrorStr: "unknown_preset",
+			}
+		}
+		gameRequest.Rows = preset.Rows
+		gameRequest.Columns = preset.Columns
+		gameRequest.Mines = preset.Mines
+	}
+
+	cells := gameRequest.Columns * gameRequest.Rows
+	if gameRequest.Topology == constants.Topology3D {
+		cells *= gameRequest.Layers
+	}
+	if gameRequest.Mines > cells {
 		return nil, &errors.ApiError{
 			Message:  "Too many mines",
 			ErrorStr: "too_many_mines",
@@ -65,31 +228,14 @@ func (gs *GameService) CreateGame(gameRequest *domain.NewGameConditionsRequest)
 			ErrorStr: "mines_count_at_least_one",
 		}
 	}
-	newGame, err := createNewGameFromRequest(gameRequest)
+	newGame, err := gs.createNewGameFromRequest(gameRequest)
 	if err != nil {
 		return nil, err
 	}
 
-	userGames, _ := gs.InMemoryContainer.Get(gameRequest.UserID)
-	if userGames == nil {
-		newUser = true
-		userGames = &domain.UserGame{
-			UserID: gameRequest.UserID,
-			Games:  make([]*domain.Game, 0),
-		}
-	}
-
-	userGames.Games = append(userGames.Games, newGame)
-
-	if newUser {
-		insertErr := gs.InMemoryContainer.Insert(userGames)
-		if insertErr != nil {
-			return nil, insertErr
-		}
-	}
-	updateErr := gs.InMemoryContainer.Update(userGames)
-	if updateErr != nil {
-		return nil, updateErr
+	insertErr := gs.Repository.InsertGame(gameRequest.UserID, newGame)
+	if insertErr != nil {
+		return nil, insertErr
 	}
 	return newGame, nil
 }
@@ -104,7 +250,7 @@ func (gs *GameService) ShowSolution(userID string, gameID int64) (string, error)
 	if game == nil {
 		return "", nil
 	}
-	return boardSolutionToString(game.Board), nil
+	return boardSolutionToString(game), nil
 }
 
 // FlagCell flags a cell on the board
@@ -123,43 +269,30 @@ func (gs *GameService) FlagCell(flagRequest *domain.FlagCellRequest) (*domain.Ga
 		return nil, nil
 	}
 
-	if userGame.Games[gameIndex].Status != constants.GameStatusOnGoing {
-		return nil, &errors.ApiError{
-			Message:  "game is already over",
-			ErrorStr: "game_already_over",
-		}
+	game := userGame.Games[gameIndex]
+	_, playErr := nextStatus(game, eventPlay)
+	if playErr != nil {
+		return nil, playErr
 	}
 
-	if flagRequest.Column >= userGame.Games[gameIndex].Columns {
-		return nil, &errors.ApiError{
-			Message:  "flag out of boundries (columns exceeded)",
-			ErrorStr: "out_of_boundries",
-		}
+	cellPosition := position{flagRequest.Layer, flagRequest.Column, flagRequest.Row}
+	boundriesErr := checkBoundries(game, cellPosition, "flag")
+	if boundriesErr != nil {
+		return nil, boundriesErr
 	}
 
-	if flagRequest.Row >= userGame.Games[gameIndex].Rows {
-		return nil, &errors.ApiError{
-			Message:  "flag out of boundries (rows exceeded)",
-			ErrorStr: "out_of_boundries",
+	if !cellAt(layersOf(game), cellPosition).IsRevealed {
+		flagErr := gs.flagCellAt(game, cellPosition, flagRequest.Flag)
+		if flagErr != nil {
+			return nil, flagErr
 		}
-	}
-
-	if !userGame.Games[gameIndex].Board[flagRequest.Column][flagRequest.Row].IsRevealed {
-		if userGame.Games[gameIndex].Board[flagRequest.Column][flagRequest.Row].Flag == flagRequest.Flag {
-			userGame.Games[gameIndex].Board[flagRequest.Column][flagRequest.Row].Flag = ""
-		} else {
-			userGame.Games[gameIndex].Board[flagRequest.Column][flagRequest.Row].Flag = flagRequest.Flag
-		}
-		if checkIfWon(userGame.Games[gameIndex].Board, userGame.Games[gameIndex].Columns, userGame.Games[gameIndex].Rows) {
-			userGame.Games[gameIndex].Status = constants.GameResultWon
-			userGame.Games[gameIndex].Finish = time.Now()
-		}
-		updateErr := gs.InMemoryContainer.Update(userGame)
-		if err != nil {
+		gs.recordMove(game, constants.MoveFlag, cellPosition, flagRequest.Flag)
+		updateErr := gs.Repository.UpdateGame(userGame.UserID, game)
+		if updateErr != nil {
 			return nil, updateErr
 		}
 	}
-	return userGame.Games[gameIndex], nil
+	return game, nil
 }
 
 // ShowStatus shows the solution's solution
@@ -172,7 +305,7 @@ func (gs *GameService) ShowStatus(userID string, gameID int64) (string, error) {
 	if game == nil {
 		return "", nil
 	}
-	return boardToString(game.Board), nil
+	return boardToString(game), nil
 }
 
 // RevealCell reveals a cell
@@ -190,79 +323,397 @@ func (gs *GameService) RevealCell(revealCellRequest *domain.RevealCellRequest) (
 		return nil, nil
 	}
 
-	if userGame.Games[gameIndex].Status != constants.GameStatusOnGoing {
+	_, playErr := nextStatus(userGame.Games[gameIndex], eventPlay)
+	if playErr != nil {
+		return nil, playErr
+	}
+
+	cellPosition := position{revealCellRequest.Layer, revealCellRequest.Column, revealCellRequest.Row}
+	boundriesErr := checkBoundries(userGame.Games[gameIndex], cellPosition, "reveal")
+	if boundriesErr != nil {
+		return nil, boundriesErr
+	}
+
+	game, err := gs.revealCellAt(userGame.Games[gameIndex], cellPosition)
+	if err != nil {
+		return nil, err
+	}
+	gs.recordMove(game, constants.MoveReveal, cellPosition, "")
+
+	userGame.Games[gameIndex] = game
+	updateErr := gs.Repository.UpdateGame(userGame.UserID, game)
+	if updateErr != nil {
+		return nil, updateErr
+	}
+	return userGame.Games[gameIndex], nil
+}
+
+// RevealCellFloodFill reveals a cell of a flat board and its adjacents
+func (gs *GameService) RevealCellFloodFill(game *domain.Game, column, row int) (*domain.Game, error) {
+	return gs.revealCellAt(game, position{0, column, row})
+}
+
+// revealCellAt reveals a cell and its adjacents. Revealing a cell again is rejected, so an
+// exploded mine can't take more than one life
+func (gs *GameService) revealCellAt(game *domain.Game, p position) (*domain.Game, error) {
+
+	err := applyEvent(game, eventPlay, gs.Now())
+	if err != nil {
+		return nil, err
+	}
+	if cellAt(layersOf(game), p).IsRevealed {
 		return nil, &errors.ApiError{
-			Message:  "game is already over",
-			ErrorStr: "game_already_over",
+			Message:  "the cell is already revealed",
+			ErrorStr: "cell_already_revealed",
 		}
 	}
 
-	if revealCellRequest.Column >= userGame.Games[gameIndex].Columns {
+	if game.FirstClick != "" && !game.FirstClickDone {
+		if game.NoGuess {
+			game.Solvable = generateNoGuessBoard(game, p, gs.randomSource(game), noGuessMaxWork)
+		}
+		if !game.Solvable {
+			protectFirstClick(game, p, gs.randomSource(game))
+		}
+		game.FirstClickDone = true
+		game.MineLocations = mineLocations(layersOf(game), newGrid(game))
+	}
+
+	board := layersOf(game)
+	if cellAt(board, p).HasMine {
+		gs.explodeMine(game, cellAt(board, p))
+	} else {
+		revealCell(board, newGrid(game), p)
+	}
+	if game.Status == constants.GameStatusOnGoing && checkIfWon(board) {
+		applyEvent(game, eventWin, gs.Now())
+	}
+	return game, nil
+}
+
+// flagCellAt sets the flag of a hidden cell, or removes it when the cell already has it
+func (gs *GameService) flagCellAt(game *domain.Game, p position, flag string) error {
+
+	err := applyEvent(game, eventPlay, gs.Now())
+	if err != nil {
+		return err
+	}
+
+	board := layersOf(game)
+	cell := cellAt(board, p)
+	if cell.Flag == flag {
+		cell.Flag = ""
+	} else {
+		cell.Flag = flag
+	}
+	if checkIfWon(board) {
+		applyEvent(game, eventWin, gs.Now())
+	}
+	return nil
+}
+
+// explodeMine reveals a mine hit by the player and takes one of its lives,
+// the game is lost once there are no lives left
+func (gs *GameService) explodeMine(game *domain.Game, cell *domain.Cell) {
+
+	cell.IsRevealed = true
+	cell.Exploded = true
+	game.Explosions++
+	if game.Lives > 0 {
+		game.Lives--
+	}
+	if game.Lives == 0 && game.Status == constants.GameStatusOnGoing {
+		applyEvent(game, eventLose, gs.Now())
+	}
+}
+
+// ChordCell reveals all the neighbours of a revealed cell whose mines are already flagged
+func (gs *GameService) ChordCell(chordCellRequest *domain.RevealCellRequest) (*domain.Game, error) {
+	userGame, err := gs.GetGamesByUserID(chordCellRequest.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if userGame == nil {
+		return nil, nil
+	}
+
+	gameIndex := getGameIndex(chordCellRequest.GameID, userGame)
+	if gameIndex == -1 {
+		return nil, nil
+	}
+
+	_, playErr := nextStatus(userGame.Games[gameIndex], eventPlay)
+	if playErr != nil {
+		return nil, playErr
+	}
+
+	cellPosition := position{chordCellRequest.Layer, chordCellRequest.Column, chordCellRequest.Row}
+	boundriesErr := checkBoundries(userGame.Games[gameIndex], cellPosition, "chord")
+	if boundriesErr != nil {
+		return nil, boundriesErr
+	}
+
+	game, err := gs.chordCellAt(userGame.Games[gameIndex], cellPosition)
+	if err != nil {
+		return nil, err
+	}
+	gs.recordMove(game, constants.MoveChord, cellPosition, "")
+
+	userGame.Games[gameIndex] = game
+	updateErr := gs.Repository.UpdateGame(userGame.UserID, game)
+	if updateErr != nil {
+		return nil, updateErr
+	}
+	return userGame.Games[gameIndex], nil
+}
+
+// ChordCellNeighbours reveals every neighbour of a revealed cell of a flat board that is not
+// red flagged, as long as the number of red flags around it matches its number.
+// If a flag was misplaced a mine gets revealed and the game is lost
+func (gs *GameService) ChordCellNeighbours(game *domain.Game, column, row int) (*domain.Game, error) {
+	return gs.chordCellAt(game, position{0, column, row})
+}
+
+// chordCellAt is ChordCellNeighbours for any kind of board
+func (gs *GameService) chordCellAt(game *domain.Game, p position) (*domain.Game, error) {
+
+	board := layersOf(game)
+	cell := cellAt(board, p)
+	if !cell.IsRevealed || cell.HasMine {
 		return nil, &errors.ApiError{
-			Message:  "flag out of boundries (columns exceeded)",
-			ErrorStr: "out_of_boundries",
+			Message:  "only revealed numbers can be chorded",
+			ErrorStr: "cell_not_revealed",
 		}
 	}
 
-	if revealCellRequest.Row >= userGame.Games[gameIndex].Rows {
+	boardGrid := newGrid(game)
+	neighbours := boardGrid.neighbours(p)
+	flagsCount := 0
+	for _, neighbour := range neighbours {
+		if cellAt(board, neighbour).Flag == constants.FlagRedFlag || cellAt(board, neighbour).Exploded {
+			flagsCount++
+		}
+	}
+	if flagsCount != cell.SourroundedBy {
 		return nil, &errors.ApiError{
-			Message:  "flag out of boundries (rows exceeded)",
+			Message:  "the red flags around the cell must match its number",
+			ErrorStr: "flags_mismatch",
+		}
+	}
+
+	err := applyEvent(game, eventPlay, gs.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, neighbour := range neighbours {
+		neighbourCell := cellAt(board, neighbour)
+		if neighbourCell.IsRevealed || neighbourCell.Flag == constants.FlagRedFlag {
+			continue
+		}
+		if neighbourCell.HasMine {
+			gs.explodeMine(game, neighbourCell)
+		} else {
+			revealCell(board, boardGrid, neighbour)
+		}
+		if game.IsOver() {
+			break
+		}
+	}
+
+	if game.Status == constants.GameStatusOnGoing && checkIfWon(board) {
+		applyEvent(game, eventWin, gs.Now())
+	}
+	return game, nil
+}
+
+// checkBoundries returns an out_of_boundries error if the position is not inside the board
+func checkBoundries(game *domain.Game, p position, action string) error {
+
+	boardGrid := newGrid(game)
+	if p.column >= boardGrid.columns {
+		return &errors.ApiError{
+			Message:  action + " out of boundries (columns exceeded)",
+			ErrorStr: "out_of_boundries",
+		}
+	}
+	if p.row >= boardGrid.rows {
+		return &errors.ApiError{
+			Message:  action + " out of boundries (rows exceeded)",
 			ErrorStr: "out_of_boundries",
 		}
 	}
+	if p.layer >= boardGrid.layers {
+		return &errors.ApiError{
+			Message:  action + " out of boundries (layers exceeded)",
+			ErrorStr: "out_of_boundries",
+		}
+	}
+	return nil
+}
 
-	game, err := gs.RevealCellFloodFill(userGame.Games[gameIndex], revealCellRequest.Column, revealCellRequest.Row)
+// PauseGame pauses a game on going, its time stops running until it's resumed
+func (gs *GameService) PauseGame(userID string, gameID int64) (*domain.Game, error) {
+	userGame, err := gs.GetGamesByUserID(userID)
 	if err != nil {
 		return nil, err
 	}
+	if userGame == nil {
+		return nil, nil
+	}
 
-	userGame.Games[gameIndex] = game
-	updateErr := gs.InMemoryContainer.Update(userGame)
+	gameIndex := getGameIndex(gameID, userGame)
+	if gameIndex == -1 {
+		return nil, nil
+	}
+
+	game := userGame.Games[gameIndex]
+	if game.TimeLimit > 0 {
+		return nil, &errors.ApiError{
+			Message:  "timed games can't be paused",
+			ErrorStr: "timed_game",
+		}
+	}
+
+	pauseErr := applyEvent(game, eventPause, gs.Now())
+	if pauseErr != nil {
+		return nil, pauseErr
+	}
+	updateErr := gs.Repository.UpdateGame(userGame.UserID, game)
+	if updateErr != nil {
+		return nil, updateErr
+	}
+	return game, nil
+}
+
+// ResumeGame resumes a paused game
+func (gs *GameService) ResumeGame(userID string, gameID int64) (*domain.Game, error) {
+	userGame, err := gs.GetGamesByUserID(userID)
 	if err != nil {
+		return nil, err
+	}
+	if userGame == nil {
+		return nil, nil
+	}
+
+	gameIndex := getGameIndex(gameID, userGame)
+	if gameIndex == -1 {
+		return nil, nil
+	}
+
+	game := userGame.Games[gameIndex]
+	resumeErr := applyEvent(game, eventResume, gs.Now())
+	if resumeErr != nil {
+		return nil, resumeErr
+	}
+	updateErr := gs.Repository.UpdateGame(userGame.UserID, game)
+	if updateErr != nil {
 		return nil, updateErr
 	}
-	return userGame.Games[gameIndex], nil
+	return game, nil
 }
 
-// RevealCellFloodFill reveals a cell and its adjacents
-func (gs *GameService) RevealCellFloodFill(game *domain.Game, column, row int) (*domain.Game, error) {
+// AbandonGame ends a game that is not over yet without winning or losing it
+func (gs *GameService) AbandonGame(userID string, gameID int64) (*domain.Game, error) {
+	userGame, err := gs.GetGamesByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if userGame == nil {
+		return nil, nil
+	}
 
-	if game.Board[column][row].HasMine {
-		game.Board[column][row].IsRevealed = true
-		game.Status = constants.GameStatusLose
-		game.Finish = time.Now()
-	} else {
-		revealCell(game.Board, column, row, game.Columns, game.Rows)
-		if checkIfWon(game.Board, game.Columns, game.Rows) {
-			game.Status = constants.GameResultWon
-			game.Finish = time.Now()
-		}
+	gameIndex := getGameIndex(gameID, userGame)
+	if gameIndex == -1 {
+		return nil, nil
+	}
+
+	game := userGame.Games[gameIndex]
+	abandonErr := applyEvent(game, eventAbandon, gs.Now())
+	if abandonErr != nil {
+		return nil, abandonErr
+	}
+	updateErr := gs.Repository.UpdateGame(userGame.UserID, game)
+	if updateErr != nil {
+		return nil, updateErr
 	}
 	return game, nil
 }
 
 // DeleteAllGames deletes all games
 func (gs *GameService) DeleteAllGames() error {
-	err := gs.InMemoryContainer.DeleteAll()
+	err := gs.Repository.DeleteAll()
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// DeleteGame deletes a game of a user, returning false if it doesn't exist
+func (gs *GameService) DeleteGame(userID string, gameID int64) (bool, error) {
+	return gs.Repository.DeleteGame(userID, gameID)
+}
+
+// DeleteUserGames deletes a user and all its games, returning false if the user doesn't exist
+func (gs *GameService) DeleteUserGames(userID string) (bool, error) {
+	return gs.Repository.Delete(userID)
+}
+
 // GetAllGames gets all games
 func (gs *GameService) GetAllGames() ([]*domain.UserGame, error) {
-	games, err := gs.InMemoryContainer.GetAll()
+	games, err := gs.Repository.GetAll()
 	if err != nil {
 		return nil, err
 	}
+	for _, userGame := range games {
+		err = gs.refreshGames(userGame)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return games, nil
 }
 
+// refreshGames migrates the legacy statuses of the games of the user and ends the ones whose
+// time limit ran out, storing the ones that changed
+func (gs *GameService) refreshGames(userGame *domain.UserGame) error {
+
+	now := gs.Now()
+	for _, game := range userGame.Games {
+		if refreshGame(game, now) {
+			err := gs.Repository.UpdateGame(userGame.UserID, game)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// refreshGame migrates the legacy status of the game and ends it if its time limit ran out,
+// returning if it changed
+func refreshGame(game *domain.Game, now time.Time) bool {
+
+	changed := migrateStatus(game)
+	deadline, timed := game.Deadline()
+	if timed && !now.Before(deadline) && applyEvent(game, eventTimeOut, deadline) == nil {
+		changed = true
+	}
+	return changed
+}
+
+// Now returns the current time of the service clock
+func (gs *GameService) Now() time.Time {
+	if gs.Clock != nil {
+		return gs.Clock()
+	}
+	return time.Now()
+}
+
 // GetRevealedCellsCount get the cells revealed count
 func GetRevealedCellsCount(board [][]domain.Cell, columns, rows int) int {
 	revealedCellsCount := 0
-	for i := 0; i < rows; i++ {
+	for i := 0; i < columns; i++ {
 		for j := 0; j < rows; j++ {
 			if board[i][j].IsRevealed {
 				revealedCellsCount++
@@ -272,30 +723,49 @@ func GetRevealedCellsCount(board [][]domain.Cell, columns, rows int) int {
 	return revealedCellsCount
 }
 
-func getCellsNotRevealedWithMinesCount(board [][]domain.Cell, columns, rows int) int {
+func getCellsNotRevealedWithMinesCount(board [][][]domain.Cell) int {
 	cellsCount := 0
-	for i := 0; i < rows; i++ {
-		for j := 0; j < rows; j++ {
-			if !board[i][j].IsRevealed && board[i][j].HasMine {
-				cellsCount++
+	for _, layer := range board {
+		for _, column := range layer {
+			for _, cell := range column {
+				if !cell.IsRevealed && cell.HasMine {
+					cellsCount++
+				}
 			}
 		}
 	}
 	return cellsCount
 }
 
-func checkIfWon(board [][]domain.Cell, columns, rows int) bool {
+func getExplodedCellsCount(board [][][]domain.Cell) int {
+	cellsCount := 0
+	for _, layer := range board {
+		for _, column := range layer {
+			for _, cell := range column {
+				if cell.Exploded {
+					cellsCount++
+				}
+			}
+		}
+	}
+	return cellsCount
+}
 
-	if getCellsNotRevealedWithMinesCount(board, columns, rows) == 0 {
+// checkIfWon tells if every safe cell is revealed and every mine is red flagged or exploded
+func checkIfWon(board [][][]domain.Cell) bool {
+
+	if getCellsNotRevealedWithMinesCount(board) == 0 && getExplodedCellsCount(board) == 0 {
 		return true
 	}
-	for i := 0; i < columns; i++ {
-		for j := 0; j < rows; j++ {
-			if board[i][j].HasMine && board[i][j].Flag != constants.FlagRedFlag {
-				return false
-			}
-			if !board[i][j].HasMine && !board[i][j].IsRevealed {
-				return false
+	for _, layer := range board {
+		for _, column := range layer {
+			for _, cell := range column {
+				if cell.HasMine && !cell.Exploded && cell.Flag != constants.FlagRedFlag {
+					return false
+				}
+				if !cell.HasMine && !cell.IsRevealed {
+					return false
+				}
 			}
 		}
 	}
@@ -318,122 +788,192 @@ func getAllRedFlaggedAndRevealedCellsFromBoard(board [][]domain.Cell, columns, r
 	return redFlaggedCellsCount, revealedCellsCount
 }
 
-func revealCell(board [][]domain.Cell, column int, row int, totalColumns int, totalRows int) {
-	board[column][row].IsRevealed = true
-	if board[column][row].SourroundedBy == 0 {
-		for xOffset := -1; xOffset <= 1; xOffset++ {
-			for yOffset := -1; yOffset <= 1; yOffset++ {
-				i := column + xOffset
-				j := row + yOffset
-				if i > -1 && i < totalColumns && j > -1 && j < totalRows {
-					neighbour := board[i][j]
-					if !neighbour.HasMine && !neighbour.IsRevealed && neighbour.Flag != constants.FlagRedFlag && neighbour.Flag != constants.FlagQuestionMark {
-						revealCell(board, i, j, totalColumns, totalRows)
-					}
-				}
+func revealCell(board [][][]domain.Cell, boardGrid grid, p position) {
+	cellAt(board, p).IsRevealed = true
+	if cellAt(board, p).SourroundedBy == 0 {
+		for _, neighbourPosition := range boardGrid.neighbours(p) {
+			neighbour := cellAt(board, neighbourPosition)
+			if !neighbour.HasMine && !neighbour.IsRevealed && neighbour.Flag != constants.FlagRedFlag && neighbour.Flag != constants.FlagQuestionMark {
+				revealCell(board, boardGrid, neighbourPosition)
 			}
 		}
 	}
 }
 
-func createNewGameFromRequest(gameRequest *domain.NewGameConditionsRequest) (*domain.Game, error) {
+func (gs *GameService) createNewGameFromRequest(gameRequest *domain.NewGameConditionsRequest) (*domain.Game, error) {
 
-	gameID := generateUniqueID()
+	firstClick := gameRequest.FirstClick
+	if gameRequest.NoGuess {
+		firstClick = constants.FirstClickOpening
+	} else if firstClick == "" {
+		firstClick = constants.FirstClickSafeCell
+	}
+
+	topology := gameRequest.Topology
+	if topology == "" {
+		topology = constants.TopologySquare
+	}
+	layers := 0
+	if topology == constants.Topology3D {
+		layers = gameRequest.Layers
+	}
+
+	lives := gameRequest.Lives
+	if lives == 0 {
+		lives = 1
+	}
+
+	seed := generateSeed()
+	if gameRequest.Ranked {
+		seed = 0
+	} else if gameRequest.Seed != nil {
+		seed = *gameRequest.Seed
+	}
+	now := gs.Now()
 	newGame := &domain.Game{
-		Mines:   gameRequest.Mines,
-		Start:   time.Now(),
-		Columns: gameRequest.Columns,
-		Rows:    gameRequest.Rows,
-		Status:  constants.GameStatusOnGoing,
-		Board:   initializeBoard(gameRequest.Columns, gameRequest.Rows, gameRequest.Mines, gameID),
-		GameID:  gameID,
+		Mines:       gameRequest.Mines,
+		Start:       now,
+		ResumedAt:   now,
+		Columns:     gameRequest.Columns,
+		Rows:        gameRequest.Rows,
+		Status:      constants.GameStatusCreated,
+		GameID:      generateUniqueID(),
+		UserID:      gameRequest.UserID,
+		Seed:        seed,
+		FirstClick:  firstClick,
+		Preset:      gameRequest.Preset,
+		NoGuess:     gameRequest.NoGuess,
+		Ranked:      gameRequest.Ranked,
+		Topology:    topology,
+		Wrap:        gameRequest.Wrap,
+		Layers:      layers,
+		Lives:       lives,
+		MaxLives:    lives,
+		UndoEnabled: !gameRequest.Ranked || gs.UndoRankedGames,
+		Moves:       []domain.Move{},
+		TimeLimit:   gameRequest.TimeLimit,
 	}
+	setLayers(newGame, initializeBoard(newGrid(newGame), gameRequest.Mines, gs.randomSource(newGame)))
+	newGame.MineLocations = mineLocations(layersOf(newGame), newGrid(newGame))
 	return newGame, nil
 }
 
-func initializeBoard(columns int, rows int, mines int, seed int64) [][]domain.Cell {
+func initializeBoard(boardGrid grid, mines int, random RandomSource) [][][]domain.Cell {
 
-	boardWithMines := placeMines(columns, rows, mines, seed)
-	boardComplete := setNeighgoursCount(boardWithMines, columns, rows)
+	boardWithMines := placeMines(boardGrid, mines, random)
+	boardComplete := setNeighgoursCount(boardWithMines, boardGrid)
 	return boardComplete
 }
 
-func placeMines(columns int, rows int, mines int, seed int64) [][]domain.Cell {
+func placeMines(boardGrid grid, mines int, random RandomSource) [][][]domain.Cell {
 
-	newBoard := make([][]domain.Cell, columns)
-	for col := range newBoard {
-		newBoard[col] = make([]domain.Cell, rows)
-	}
+	newBoard := boardGrid.newBoard()
 
-	rand.Seed(seed)
 	minesLeftToPlace := mines
 	for minesLeftToPlace > 0 {
-		columnNumber := rand.Intn(columns)
-		rowNumber := rand.Intn(rows)
-		if !newBoard[columnNumber][rowNumber].HasMine {
-			newBoard[columnNumber][rowNumber].HasMine = true
+		layerNumber := 0
+		if boardGrid.layers > 1 {
+			layerNumber = random.Intn(boardGrid.layers)
+		}
+		columnNumber := random.Intn(boardGrid.columns)
+		rowNumber := random.Intn(boardGrid.rows)
+		if !newBoard[layerNumber][columnNumber][rowNumber].HasMine {
+			newBoard[layerNumber][columnNumber][rowNumber].HasMine = true
 			minesLeftToPlace--
 		}
 	}
 	return newBoard
 }
 
-func setNeighgoursCount(board [][]domain.Cell, columns int, rows int) [][]domain.Cell {
+// protectFirstClick moves the mines out of the first revealed cell, and of its
+// neighbours when an opening is guaranteed, and recomputes the neighbour counts.
+// If there is no room left for an opening only the revealed cell is cleared
+func protectFirstClick(game *domain.Game, p position, random RandomSource) {
 
-	for i := 0; i < columns; i++ {
-		for j := 0; j < rows; j++ {
-			board[i][j].SourroundedBy = countNeighbours(i, j, board, columns, rows)
-		}
+	boardGrid := newGrid(game)
+	board := layersOf(game)
+	safeCells := []position{p}
+	if game.FirstClick == constants.FirstClickOpening {
+		safeCells = boardGrid.area(p)
 	}
-	return board
+
+	if !relocateMines(board, boardGrid, safeCells, random) && len(safeCells) > 1 {
+		relocateMines(board, boardGrid, []position{p}, random)
+	}
+	setNeighgoursCount(board, boardGrid)
 }
 
-func countNeighbours(x int, y int, board [][]domain.Cell, columns int, rows int) int {
+// relocateMines moves every mine placed in safeCells to a random free cell outside
+// of them. It returns false, leaving the board untouched, if there is not enough room
+func relocateMines(board [][][]domain.Cell, boardGrid grid, safeCells []position, random RandomSource) bool {
 
-	totalNeighbours := 0
-	for xOffset := -1; xOffset <= 1; xOffset++ {
-		for yOffset := -1; yOffset <= 1; yOffset++ {
-			i := x + xOffset
-			j := y + yOffset
-			if i > -1 && i < columns && j > -1 && j < rows {
-				neighbour := board[i][j]
-				if neighbour.HasMine {
-					totalNeighbours++
-				}
-			}
+	isSafe := make(map[position]bool, len(safeCells))
+	minesToMove := 0
+	for _, cell := range safeCells {
+		isSafe[cell] = true
+		if cellAt(board, cell).HasMine {
+			minesToMove++
 		}
 	}
-	return totalNeighbours
+	if minesToMove == 0 {
+		return true
+	}
+
+	freeCells := make([]position, 0)
+	for _, cell := range boardGrid.positions() {
+		if !cellAt(board, cell).HasMine && !isSafe[cell] {
+			freeCells = append(freeCells, cell)
+		}
+	}
+	if len(freeCells) < minesToMove {
+		return false
+	}
+
+	for _, cell := range safeCells {
+		if !cellAt(board, cell).HasMine {
+			continue
+		}
+		cellAt(board, cell).HasMine = false
+		index := random.Intn(len(freeCells))
+		cellAt(board, freeCells[index]).HasMine = true
+		freeCells[index] = freeCells[len(freeCells)-1]
+		freeCells = freeCells[:len(freeCells)-1]
+	}
+	return true
 }
 
-func generateUniqueID() int64 {
-	return time.Now().UnixNano() / int64(time.Microsecond)
+func setNeighgoursCount(board [][][]domain.Cell, boardGrid grid) [][][]domain.Cell {
+
+	for _, cell := range boardGrid.positions() {
+		cellAt(board, cell).SourroundedBy = countNeighbours(board, boardGrid, cell)
+	}
+	return board
 }
 
-func boardSolutionToString(board [][]domain.Cell) string {
+// countNeighbours counts the mines in the area of the cell, including its own
+func countNeighbours(board [][][]domain.Cell, boardGrid grid, p position) int {
 
-	stringBoard := ""
-	for i := range board {
-		for _, cell := range board[i] {
-			if cell.HasMine {
-				stringBoard += " * "
-			} else if cell.SourroundedBy == 0 {
-				stringBoard += " _ "
-			} else {
-				stringBoard += " " + strconv.Itoa(cell.SourroundedBy) + " "
-			}
+	totalNeighbours := 0
+	for _, cell := range boardGrid.area(p) {
+		if cellAt(board, cell).HasMine {
+			totalNeighbours++
 		}
-		stringBoard += "\n"
 	}
-	return stringBoard
+	return totalNeighbours
 }
 
-func boardToString(board [][]domain.Cell) string {
+// boardSolutionToString prints the board one row per line, see domain.Game for the coordinates.
+// The odd rows of hexagonal boards are indented half a cell, the layers of three dimensional
+// boards are printed one after the other
+func boardSolutionToString(game *domain.Game) string {
 
 	stringBoard := ""
-	for i := range board {
-		for _, cell := range board[i] {
-			if cell.IsRevealed {
+	for layer, cells := range layersOf(game) {
+		stringBoard += layerHeader(game, layer, "\n")
+		for row := 0; row < game.Rows; row++ {
+			stringBoard += rowIndentation(game, row)
+			for column := 0; column < game.Columns; column++ {
+				cell := cells[column][row]
 				if cell.HasMine {
 					stringBoard += " * "
 				} else if cell.SourroundedBy == 0 {
@@ -441,19 +981,59 @@ func boardToString(board [][]domain.Cell) string {
 				} else {
 					stringBoard += " " + strconv.Itoa(cell.SourroundedBy) + " "
 				}
-			} else if cell.Flag == constants.FlagRedFlag {
-				stringBoard += " F "
-			} else if cell.Flag == constants.FlagQuestionMark {
-				stringBoard += " ? "
-			} else {
-				stringBoard += " □ "
 			}
+			stringBoard += "\n"
 		}
-		stringBoard += "|"
 	}
 	return stringBoard
 }
 
+// boardToString prints the board as seen by the player, rows are separated by '|'
+func boardToString(game *domain.Game) string {
+
+	stringBoard := ""
+	for layer, cells := range layersOf(game) {
+		stringBoard += layerHeader(game, layer, "|")
+		for row := 0; row < game.Rows; row++ {
+			stringBoard += rowIndentation(game, row)
+			for column := 0; column < game.Columns; column++ {
+				cell := cells[column][row]
+				if cell.IsRevealed {
+					if cell.HasMine {
+						stringBoard += " * "
+					} else if cell.SourroundedBy == 0 {
+						stringBoard += " _ "
+					} else {
+						stringBoard += " " + strconv.Itoa(cell.SourroundedBy) + " "
+					}
+				} else if cell.Flag == constants.FlagRedFlag {
+					stringBoard += " F "
+				} else if cell.Flag == constants.FlagQuestionMark {
+					stringBoard += " ? "
+				} else {
+					stringBoard += " □ "
+				}
+			}
+			stringBoard += "|"
+		}
+	}
+	return stringBoard
+}
+
+func rowIndentation(game *domain.Game, row int) string {
+	if game.Topology == constants.TopologyHex && row%2 == 1 {
+		return "  "
+	}
+	return ""
+}
+
+func layerHeader(game *domain.Game, layer int, separator string) string {
+	if game.Topology == constants.Topology3D {
+		return "layer " + strconv.Itoa(layer) + separator
+	}
+	return ""
+}
+
 func getGameIndex(gameID int64, userGame *domain.UserGame) int {
 	for i, game := range userGame.Games {
 		if game.GameID == gameID {
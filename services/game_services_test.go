@@ -1,7 +1,11 @@
 package services
 
 import (
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/mercadolibre/minesweeper/constants"
 	"github.com/mercadolibre/minesweeper/dao"
@@ -50,7 +54,7 @@ func TestGameCreation(t *testing.T) {
 	}
 
 	gameService := &GameService{
-		InMemoryContainer: *dao.CreateInMemoryContainer(),
+		Repository: dao.CreateInMemoryContainer(),
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -89,7 +93,7 @@ func TestMinesCount(t *testing.T) {
 	}
 
 	gameService := &GameService{
-		InMemoryContainer: *dao.CreateInMemoryContainer(),
+		Repository: dao.CreateInMemoryContainer(),
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -146,7 +150,7 @@ func TestRevealCell(t *testing.T) {
 	}
 
 	gameService := &GameService{
-		InMemoryContainer: *dao.CreateInMemoryContainer(),
+		Repository: dao.CreateInMemoryContainer(),
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -253,7 +257,7 @@ func TestFlagCell(t *testing.T) {
 	}
 
 	gameService := &GameService{
-		InMemoryContainer: *dao.CreateInMemoryContainer(),
+		Repository: dao.CreateInMemoryContainer(),
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -306,7 +310,7 @@ func TestGetGamesByUserID(t *testing.T) {
 	}
 
 	gameService := &GameService{
-		InMemoryContainer: *dao.CreateInMemoryContainer(),
+		Repository: dao.CreateInMemoryContainer(),
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -370,7 +374,7 @@ func TestGameWinOrLose(t *testing.T) {
 					},
 				},
 			},
-			expectedStatus: constants.GameStatusLose,
+			expectedStatus: constants.GameStatusLost,
 		},
 		{
 			name: "OK/WIN",
@@ -418,7 +422,7 @@ func TestGameWinOrLose(t *testing.T) {
 	}
 
 	gameService := &GameService{
-		InMemoryContainer: *dao.CreateInMemoryContainer(),
+		Repository: dao.CreateInMemoryContainer(),
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -427,3 +431,1486 @@ func TestGameWinOrLose(t *testing.T) {
 		})
 	}
 }
+func TestFirstClickSafety(t *testing.T) {
+	cases := []struct {
+		name                  string
+		gameConditionsRequest *domain.NewGameConditionsRequest
+		safeCells             int
+	}{
+		{
+			name: "OK/SAFE_CELL",
+			gameConditionsRequest: &domain.NewGameConditionsRequest{
+				UserID:     "test_user",
+				Rows:       5,
+				Columns:    5,
+				Mines:      24,
+				FirstClick: constants.FirstClickSafeCell,
+			},
+			safeCells: 1,
+		},
+		{
+			name: "OK/OPENING",
+			gameConditionsRequest: &domain.NewGameConditionsRequest{
+				UserID:     "test_user",
+				Rows:       5,
+				Columns:    5,
+				Mines:      16,
+				FirstClick: constants.FirstClickOpening,
+			},
+			safeCells: 9,
+		},
+		{
+			name: "OK/OPENING_WITHOUT_ROOM",
+			gameConditionsRequest: &domain.NewGameConditionsRequest{
+				UserID:     "test_user",
+				Rows:       5,
+				Columns:    5,
+				Mines:      20,
+				FirstClick: constants.FirstClickOpening,
+			},
+			safeCells: 1,
+		},
+	}
+
+	gameService := &GameService{
+		Repository: dao.CreateInMemoryContainer(),
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			newGame, _ := gameService.CreateGame(c.gameConditionsRequest)
+			game, _ := gameService.RevealCell(&domain.RevealCellRequest{
+				UserID: c.gameConditionsRequest.UserID,
+				GameID: newGame.GameID,
+				Row:    2,
+				Column: 2,
+			})
+
+			minesCount := 0
+			safeCells := 0
+			for i := 0; i < game.Columns; i++ {
+				for j := 0; j < game.Rows; j++ {
+					if game.Board[i][j].HasMine {
+						minesCount++
+					} else if i >= 1 && i <= 3 && j >= 1 && j <= 3 {
+						safeCells++
+					}
+					assert.Equal(t, game.Board[i][j].SourroundedBy, countNeighbours(layersOf(game), newGrid(game), position{0, i, j}))
+				}
+			}
+			assert.NotEqual(t, game.Status, constants.GameStatusLost)
+			assert.Equal(t, minesCount, c.gameConditionsRequest.Mines)
+			assert.True(t, safeCells >= c.safeCells)
+			assert.True(t, game.FirstClickDone)
+		})
+	}
+}
+func TestChordCell(t *testing.T) {
+	cases := []struct {
+		name           string
+		flags          [][2]int
+		expectedStatus string
+		expectedError  bool
+	}{
+		{
+			name:           "OK/WIN",
+			flags:          [][2]int{{0, 0}},
+			expectedStatus: constants.GameResultWon,
+		},
+		{
+			name:           "OK/LOSE_MISPLACED_FLAG",
+			flags:          [][2]int{{0, 1}},
+			expectedStatus: constants.GameStatusLost,
+		},
+		{
+			name:          "FAIL/FLAGS_MISMATCH",
+			flags:         [][2]int{},
+			expectedError: true,
+		},
+	}
+
+	gameService := &GameService{
+		Repository: dao.CreateInMemoryContainer(),
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			board := placeMines(grid{layers: 1, columns: 3, rows: 3}, 0, NewSeededRandomSource(1))[0]
+			board[0][0].HasMine = true
+			board = setNeighgoursCount([][][]domain.Cell{board}, grid{layers: 1, columns: 3, rows: 3})[0]
+			board[1][1].IsRevealed = true
+			for _, flag := range c.flags {
+				board[flag[0]][flag[1]].Flag = constants.FlagRedFlag
+			}
+			game := &domain.Game{
+				GameID:  1,
+				Rows:    3,
+				Columns: 3,
+				Mines:   1,
+				Status:  constants.GameStatusOnGoing,
+				Board:   board,
+			}
+
+			chordedGame, err := gameService.ChordCellNeighbours(game, 1, 1)
+
+			if c.expectedError {
+				assert.NotNil(t, err)
+			} else {
+				assert.Equal(t, chordedGame.Status, c.expectedStatus)
+				// The chord stops at the mine that loses the game, the cells after it stay hidden
+				assert.Equal(t, chordedGame.Board[2][2].IsRevealed, c.expectedStatus == constants.GameResultWon)
+			}
+		})
+	}
+}
+func TestChordStopsWhenLost(t *testing.T) {
+	boardGrid := grid{layers: 1, columns: 3, rows: 3}
+	board := placeMines(boardGrid, 0, NewSeededRandomSource(1))[0]
+	board[0][0].HasMine = true
+	board[0][2].HasMine = true
+	board = setNeighgoursCount([][][]domain.Cell{board}, boardGrid)[0]
+	board[1][1].IsRevealed = true
+	board[2][0].Flag = constants.FlagRedFlag
+	board[2][2].Flag = constants.FlagRedFlag
+	game := &domain.Game{
+		GameID:  1,
+		Rows:    3,
+		Columns: 3,
+		Mines:   2,
+		Lives:   1,
+		Status:  constants.GameStatusOnGoing,
+		Board:   board,
+	}
+
+	gameService := &GameService{
+		Repository: dao.CreateInMemoryContainer(),
+	}
+	chordedGame, err := gameService.ChordCellNeighbours(game, 1, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, chordedGame.Status, constants.GameStatusLost)
+	assert.Equal(t, chordedGame.Lives, 0)
+	assert.Equal(t, chordedGame.Explosions, 1)
+}
+func TestRectangularBoard(t *testing.T) {
+	cases := []struct {
+		name                  string
+		gameConditionsRequest *domain.NewGameConditionsRequest
+		revealCellRequest     *domain.RevealCellRequest
+	}{
+		{
+			name: "OK/EXPERT",
+			gameConditionsRequest: &domain.NewGameConditionsRequest{
+				UserID:  "test_user",
+				Rows:    16,
+				Columns: 30,
+				Mines:   99,
+			},
+			revealCellRequest: &domain.RevealCellRequest{
+				Row:    15,
+				Column: 29,
+			},
+		},
+		{
+			name: "OK/TALL",
+			gameConditionsRequest: &domain.NewGameConditionsRequest{
+				UserID:  "test_user",
+				Rows:    7,
+				Columns: 3,
+				Mines:   4,
+			},
+			revealCellRequest: &domain.RevealCellRequest{
+				Row:    6,
+				Column: 0,
+			},
+		},
+	}
+
+	gameService := &GameService{
+		Repository: dao.CreateInMemoryContainer(),
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			newGame, _ := gameService.CreateGame(c.gameConditionsRequest)
+			assert.Equal(t, len(newGame.Board), c.gameConditionsRequest.Columns)
+			assert.Equal(t, len(newGame.Board[0]), c.gameConditionsRequest.Rows)
+
+			c.revealCellRequest.UserID = c.gameConditionsRequest.UserID
+			c.revealCellRequest.GameID = newGame.GameID
+			game, err := gameService.RevealCell(c.revealCellRequest)
+			assert.Nil(t, err)
+			assert.Equal(t, game.Board[c.revealCellRequest.Column][c.revealCellRequest.Row].IsRevealed, true)
+			assert.Equal(t, GetRevealedCellsCount(game.Board, game.Columns, game.Rows) > 0, true)
+
+			lines := strings.Split(strings.TrimSuffix(boardSolutionToString(game), "\n"), "\n")
+			assert.Equal(t, len(lines), c.gameConditionsRequest.Rows)
+			for _, line := range lines {
+				assert.Equal(t, len(line), 3*c.gameConditionsRequest.Columns)
+			}
+			statusRows := strings.Split(strings.TrimSuffix(boardToString(game), "|"), "|")
+			assert.Equal(t, len(statusRows), c.gameConditionsRequest.Rows)
+		})
+	}
+}
+func TestGameCreationFromPreset(t *testing.T) {
+	cases := []struct {
+		name                  string
+		gameConditionsRequest *domain.NewGameConditionsRequest
+		expectedPreset        *domain.Preset
+	}{
+		{
+			name: "OK/EXPERT",
+			gameConditionsRequest: &domain.NewGameConditionsRequest{
+				UserID: "test_user",
+				Preset: constants.PresetExpert,
+			},
+			expectedPreset: &domain.Preset{Name: constants.PresetExpert, Rows: 16, Columns: 30, Mines: 99},
+		},
+		{
+			name: "OK/CUSTOM",
+			gameConditionsRequest: &domain.NewGameConditionsRequest{
+				UserID: "test_user",
+				Preset: "tiny",
+			},
+			expectedPreset: &domain.Preset{Name: "tiny", Rows: 4, Columns: 3, Mines: 2},
+		},
+		{
+			name: "FAIL/UNKNOWN_PRESET",
+			gameConditionsRequest: &domain.NewGameConditionsRequest{
+				UserID: "test_user",
+				Preset: "unknown",
+			},
+			expectedPreset: nil,
+		},
+	}
+
+	gameService := &GameService{
+		Repository: dao.CreateInMemoryContainer(),
+		CustomPresets: []*domain.Preset{
+			{Name: "tiny", Rows: 4, Columns: 3, Mines: 2},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			newGame, err := gameService.CreateGame(c.gameConditionsRequest)
+
+			if c.expectedPreset == nil {
+				assert.NotNil(t, err)
+				assert.Nil(t, newGame)
+			} else {
+				assert.Equal(t, newGame.Preset, c.expectedPreset.Name)
+				assert.Equal(t, newGame.Rows, c.expectedPreset.Rows)
+				assert.Equal(t, newGame.Columns, c.expectedPreset.Columns)
+				assert.Equal(t, newGame.Mines, c.expectedPreset.Mines)
+			}
+		})
+	}
+}
+func TestValidateCustomPresets(t *testing.T) {
+	cases := []struct {
+		name          string
+		presets       []*domain.Preset
+		expectedError bool
+	}{
+		{
+			name:    "OK",
+			presets: []*domain.Preset{{Name: "tiny", Rows: 4, Columns: 3, Mines: 2}},
+		},
+		{
+			name:          "FAIL/DUPLICATED_NAME",
+			presets:       []*domain.Preset{{Name: constants.PresetBeginner, Rows: 4, Columns: 3, Mines: 2}},
+			expectedError: true,
+		},
+		{
+			name:          "FAIL/TOO_MANY_MINES",
+			presets:       []*domain.Preset{{Name: "tiny", Rows: 2, Columns: 2, Mines: 5}},
+			expectedError: true,
+		},
+		{
+			name:          "FAIL/TOO_LARGE",
+			presets:       []*domain.Preset{{Name: "huge", Rows: 31, Columns: 3, Mines: 2}},
+			expectedError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := ValidateCustomPresets(c.presets, 30, 30)
+			assert.Equal(t, err != nil, c.expectedError)
+		})
+	}
+}
+func TestNoGuessBoard(t *testing.T) {
+	cases := []struct {
+		name                  string
+		gameConditionsRequest *domain.NewGameConditionsRequest
+	}{
+		{
+			name: "OK/BEGINNER",
+			gameConditionsRequest: &domain.NewGameConditionsRequest{
+				UserID:  "test_user",
+				Preset:  constants.PresetBeginner,
+				NoGuess: true,
+			},
+		},
+		{
+			name: "OK/INTERMEDIATE",
+			gameConditionsRequest: &domain.NewGameConditionsRequest{
+				UserID:  "test_user",
+				Preset:  constants.PresetIntermediate,
+				NoGuess: true,
+			},
+		},
+	}
+
+	gameService := &GameService{
+		Repository: dao.CreateInMemoryContainer(),
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			newGame, _ := gameService.CreateGame(c.gameConditionsRequest)
+			game, _ := gameService.RevealCell(&domain.RevealCellRequest{
+				UserID: c.gameConditionsRequest.UserID,
+				GameID: newGame.GameID,
+				Row:    4,
+				Column: 4,
+			})
+
+			minesCount := 0
+			for i := 0; i < game.Columns; i++ {
+				for j := 0; j < game.Rows; j++ {
+					if game.Board[i][j].HasMine {
+						minesCount++
+					}
+				}
+			}
+			assert.Equal(t, minesCount, game.Mines)
+			assert.Equal(t, game.Solvable, true)
+			assert.Equal(t, game.Board[4][4].SourroundedBy, 0)
+			assert.Equal(t, isSolvableWithoutGuessing(layersOf(game), newGrid(game), game.Mines, position{0, 4, 4}, nil), true)
+		})
+	}
+}
+func TestNoGuessWorkLimit(t *testing.T) {
+	seed := int64(7)
+	gameService := &GameService{
+		Repository: dao.CreateInMemoryContainer(),
+	}
+	game, _ := gameService.CreateGame(&domain.NewGameConditionsRequest{
+		UserID:  "test_user",
+		Preset:  constants.PresetBeginner,
+		NoGuess: true,
+		Seed:    &seed,
+	})
+	assert.False(t, generateNoGuessBoard(game, position{0, 4, 4}, gameService.randomSource(game), 0))
+	assert.True(t, generateNoGuessBoard(game, position{0, 4, 4}, gameService.randomSource(game), noGuessMaxWork))
+
+	// A big and dense board gives up within the write timeout of the server, and the work
+	// being counted instead of the time, the same seed always gives up at the same layout
+	mineLocations := make([][]domain.CellLocation, 0)
+	for i := 0; i < 2; i++ {
+		newGame, _ := gameService.CreateGame(&domain.NewGameConditionsRequest{
+			UserID:   "test_user",
+			Rows:     30,
+			Columns:  30,
+			Layers:   10,
+			Topology: constants.Topology3D,
+			Mines:    1500,
+			NoGuess:  true,
+			Seed:     &seed,
+		})
+		start := time.Now()
+		revealedGame, err := gameService.RevealCell(&domain.RevealCellRequest{UserID: "test_user", GameID: newGame.GameID, Row: 4, Column: 4})
+		assert.Nil(t, err)
+		assert.True(t, time.Since(start) < 10*time.Second)
+		mineLocations = append(mineLocations, revealedGame.MineLocations)
+	}
+	assert.Equal(t, mineLocations[0], mineLocations[1])
+}
+func TestSolver(t *testing.T) {
+	cases := []struct {
+		name             string
+		columns          int
+		rows             int
+		mines            [][2]int
+		expectedSolvable bool
+	}{
+		{
+			name:             "OK/SOLVABLE",
+			columns:          3,
+			rows:             1,
+			mines:            [][2]int{{2, 0}},
+			expectedSolvable: true,
+		},
+		{
+			name:             "OK/SOLVABLE_WITH_SUBSETS",
+			columns:          3,
+			rows:             3,
+			mines:            [][2]int{{1, 2}},
+			expectedSolvable: true,
+		},
+		{
+			name:             "FAIL/FIFTY_FIFTY",
+			columns:          3,
+			rows:             2,
+			mines:            [][2]int{{2, 0}},
+			expectedSolvable: false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			solverGrid := grid{layers: 1, columns: c.columns, rows: c.rows}
+			board := placeMines(solverGrid, 0, NewSeededRandomSource(1))
+			for _, mine := range c.mines {
+				board[0][mine[0]][mine[1]].HasMine = true
+			}
+			board = setNeighgoursCount(board, solverGrid)
+
+			solvable := isSolvableWithoutGuessing(board, solverGrid, len(c.mines), position{0, 0, 0}, nil)
+			assert.Equal(t, solvable, c.expectedSolvable)
+		})
+	}
+}
+func TestReproducibleBoards(t *testing.T) {
+	seed := int64(42)
+	otherSeed := int64(43)
+	cases := []struct {
+		name                  string
+		gameConditionsRequest *domain.NewGameConditionsRequest
+		otherSeed             *int64
+		expectedEqual         bool
+	}{
+		{
+			name: "OK/SAME_SEED",
+			gameConditionsRequest: &domain.NewGameConditionsRequest{
+				UserID:  "test_user",
+				Rows:    8,
+				Columns: 12,
+				Mines:   30,
+				Seed:    &seed,
+			},
+			otherSeed:     &seed,
+			expectedEqual: true,
+		},
+		{
+			name: "OK/SAME_SEED_NO_GUESS",
+			gameConditionsRequest: &domain.NewGameConditionsRequest{
+				UserID:  "test_user",
+				Preset:  constants.PresetBeginner,
+				NoGuess: true,
+				Seed:    &seed,
+			},
+			otherSeed:     &seed,
+			expectedEqual: true,
+		},
+		{
+			name: "OK/DIFFERENT_SEED",
+			gameConditionsRequest: &domain.NewGameConditionsRequest{
+				UserID:  "test_user",
+				Rows:    8,
+				Columns: 12,
+				Mines:   30,
+				Seed:    &seed,
+			},
+			otherSeed:     &otherSeed,
+			expectedEqual: false,
+		},
+	}
+
+	gameService := &GameService{
+		Repository: dao.CreateInMemoryContainer(),
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			games := make([]*domain.Game, 0, 2)
+			for _, gameSeed := range []*int64{c.gameConditionsRequest.Seed, c.otherSeed} {
+				request := *c.gameConditionsRequest
+				request.Seed = gameSeed
+				newGame, _ := gameService.CreateGame(&request)
+				assert.Equal(t, newGame.Seed, *gameSeed)
+				game, _ := gameService.RevealCell(&domain.RevealCellRequest{
+					UserID: request.UserID,
+					GameID: newGame.GameID,
+					Row:    3,
+					Column: 2,
+				})
+				games = append(games, game)
+			}
+			assert.Equal(t, boardSolutionToString(games[0]) == boardSolutionToString(games[1]), c.expectedEqual)
+		})
+	}
+}
+
+type fixedRandomSource struct {
+	values []int
+	next   int
+}
+
+func (f *fixedRandomSource) Intn(n int) int {
+	value := f.values[f.next%len(f.values)] % n
+	f.next++
+	return value
+}
+
+func TestInjectedRandomSource(t *testing.T) {
+	cases := []struct {
+		name                  string
+		gameConditionsRequest *domain.NewGameConditionsRequest
+		values                []int
+		expectedMines         [][2]int
+	}{
+		{
+			name: "OK/CASUAL",
+			gameConditionsRequest: &domain.NewGameConditionsRequest{
+				UserID:  "test_user",
+				Rows:    3,
+				Columns: 3,
+				Mines:   2,
+			},
+			values:        []int{0, 1, 2, 2},
+			expectedMines: [][2]int{{0, 1}, {2, 2}},
+		},
+		{
+			name: "OK/RANKED",
+			gameConditionsRequest: &domain.NewGameConditionsRequest{
+				UserID:  "test_user",
+				Rows:    3,
+				Columns: 3,
+				Mines:   2,
+				Ranked:  true,
+			},
+			values:        []int{1, 0, 0, 2},
+			expectedMines: [][2]int{{1, 0}, {0, 2}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fixedSource := func(seed int64) RandomSource {
+				return &fixedRandomSource{values: c.values}
+			}
+			gameService := &GameService{
+				Repository: dao.CreateInMemoryContainer(),
+			}
+			if c.gameConditionsRequest.Ranked {
+				gameService.NewRankedRandomSource = fixedSource
+			} else {
+				gameService.NewRandomSource = fixedSource
+			}
+
+			newGame, _ := gameService.CreateGame(c.gameConditionsRequest)
+
+			minesCount := 0
+			for i := 0; i < newGame.Columns; i++ {
+				for j := 0; j < newGame.Rows; j++ {
+					if newGame.Board[i][j].HasMine {
+						minesCount++
+					}
+				}
+			}
+			assert.Equal(t, minesCount, len(c.expectedMines))
+			for _, mine := range c.expectedMines {
+				assert.Equal(t, newGame.Board[mine[0]][mine[1]].HasMine, true)
+			}
+		})
+	}
+}
+func TestConcurrentGameCreation(t *testing.T) {
+	seed := int64(7)
+	gameService := &GameService{
+		Repository: dao.CreateInMemoryContainer(),
+	}
+
+	games := make([]*domain.Game, 50)
+	var wg sync.WaitGroup
+	for i := range games {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			games[i], _ = gameService.CreateGame(&domain.NewGameConditionsRequest{
+				UserID: "test_user_" + strconv.Itoa(i),
+				Preset: constants.PresetExpert,
+				Seed:   &seed,
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	gameIDs := make(map[int64]bool)
+	for _, game := range games {
+		assert.Equal(t, boardSolutionToString(game), boardSolutionToString(games[0]))
+		assert.False(t, gameIDs[game.GameID])
+		gameIDs[game.GameID] = true
+	}
+}
+func TestHexagonalBoard(t *testing.T) {
+	cases := []struct {
+		name               string
+		column             int
+		row                int
+		expectedNeighbours []position
+	}{
+		{
+			name:               "OK/EVEN_ROW",
+			column:             2,
+			row:                2,
+			expectedNeighbours: []position{{0, 1, 2}, {0, 3, 2}, {0, 1, 1}, {0, 2, 1}, {0, 1, 3}, {0, 2, 3}},
+		},
+		{
+			name:               "OK/ODD_ROW",
+			column:             2,
+			row:                1,
+			expectedNeighbours: []position{{0, 1, 1}, {0, 3, 1}, {0, 2, 0}, {0, 3, 0}, {0, 2, 2}, {0, 3, 2}},
+		},
+		{
+			name:               "OK/CORNER",
+			column:             0,
+			row:                0,
+			expectedNeighbours: []position{{0, 1, 0}, {0, 0, 1}},
+		},
+	}
+
+	hexGrid := grid{topology: constants.TopologyHex, layers: 1, columns: 5, rows: 5}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			neighbours := hexGrid.neighbours(position{0, c.column, c.row})
+			assert.ElementsMatch(t, neighbours, c.expectedNeighbours)
+			for _, neighbour := range neighbours {
+				assert.Contains(t, hexGrid.neighbours(neighbour), position{0, c.column, c.row})
+			}
+		})
+	}
+
+	gameService := &GameService{
+		Repository: dao.CreateInMemoryContainer(),
+	}
+	newGame, _ := gameService.CreateGame(&domain.NewGameConditionsRequest{
+		UserID:   "test_user",
+		Rows:     6,
+		Columns:  8,
+		Mines:    8,
+		Topology: constants.TopologyHex,
+	})
+	game, _ := gameService.RevealCell(&domain.RevealCellRequest{
+		UserID: "test_user",
+		GameID: newGame.GameID,
+		Row:    3,
+		Column: 3,
+	})
+	assert.Equal(t, game.Topology, constants.TopologyHex)
+	assert.NotEqual(t, game.Status, constants.GameStatusLost)
+	for i := 0; i < game.Columns; i++ {
+		for j := 0; j < game.Rows; j++ {
+			if game.Board[i][j].IsRevealed && game.Board[i][j].SourroundedBy == 0 {
+				for _, neighbour := range newGrid(game).neighbours(position{0, i, j}) {
+					assert.True(t, game.Board[neighbour.column][neighbour.row].IsRevealed)
+				}
+			}
+		}
+	}
+	lines := strings.Split(boardSolutionToString(game), "\n")
+	assert.True(t, strings.HasPrefix(lines[1], "  "))
+	assert.False(t, strings.HasPrefix(lines[0], "  "))
+}
+func TestWrappingBoard(t *testing.T) {
+	cases := []struct {
+		name               string
+		grid               grid
+		column             int
+		row                int
+		expectedNeighbours int
+	}{
+		{
+			name:               "OK/SQUARE_CORNER",
+			grid:               grid{topology: constants.TopologySquare, layers: 1, columns: 4, rows: 5, wrap: true},
+			column:             0,
+			row:                0,
+			expectedNeighbours: 8,
+		},
+		{
+			name:               "OK/HEX_EDGE",
+			grid:               grid{topology: constants.TopologyHex, layers: 1, columns: 4, rows: 4, wrap: true},
+			column:             3,
+			row:                1,
+			expectedNeighbours: 6,
+		},
+		{
+			name:               "OK/NARROW_BOARD",
+			grid:               grid{topology: constants.TopologySquare, layers: 1, columns: 2, rows: 3, wrap: true},
+			column:             0,
+			row:                0,
+			expectedNeighbours: 5,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			neighbours := c.grid.neighbours(position{0, c.column, c.row})
+			assert.Equal(t, len(neighbours), c.expectedNeighbours)
+			for _, neighbour := range neighbours {
+				assert.Contains(t, c.grid.neighbours(neighbour), position{0, c.column, c.row})
+			}
+		})
+	}
+
+	wrappingGrid := grid{layers: 1, columns: 4, rows: 4, wrap: true}
+	board := placeMines(wrappingGrid, 0, NewSeededRandomSource(1))
+	board[0][3][3].HasMine = true
+	board = setNeighgoursCount(board, wrappingGrid)
+	assert.Equal(t, board[0][0][0].SourroundedBy, 1)
+	assert.Equal(t, board[0][0][3].SourroundedBy, 1)
+	assert.Equal(t, board[0][1][1].SourroundedBy, 0)
+}
+func TestThreeDimensionalBoard(t *testing.T) {
+	cases := []struct {
+		name               string
+		cell               position
+		expectedNeighbours int
+	}{
+		{
+			name:               "OK/CENTER",
+			cell:               position{1, 1, 1},
+			expectedNeighbours: 26,
+		},
+		{
+			name:               "OK/CORNER",
+			cell:               position{0, 0, 0},
+			expectedNeighbours: 7,
+		},
+		{
+			name:               "OK/FACE",
+			cell:               position{0, 1, 1},
+			expectedNeighbours: 17,
+		},
+	}
+
+	cubeGrid := grid{topology: constants.Topology3D, layers: 3, columns: 3, rows: 3}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			neighbours := cubeGrid.neighbours(c.cell)
+			assert.Equal(t, len(neighbours), c.expectedNeighbours)
+			for _, neighbour := range neighbours {
+				assert.Contains(t, cubeGrid.neighbours(neighbour), c.cell)
+			}
+		})
+	}
+
+	gameService := &GameService{
+		Repository: dao.CreateInMemoryContainer(),
+	}
+	newGame, _ := gameService.CreateGame(&domain.NewGameConditionsRequest{
+		UserID:   "test_user",
+		Rows:     4,
+		Columns:  5,
+		Mines:    6,
+		Topology: constants.Topology3D,
+		Layers:   3,
+	})
+	assert.Nil(t, newGame.Board)
+	assert.Equal(t, len(newGame.Board3D), 3)
+
+	_, err := gameService.RevealCell(&domain.RevealCellRequest{
+		UserID: "test_user",
+		GameID: newGame.GameID,
+		Layer:  3,
+	})
+	assert.NotNil(t, err)
+
+	game, _ := gameService.RevealCell(&domain.RevealCellRequest{
+		UserID: "test_user",
+		GameID: newGame.GameID,
+		Layer:  1,
+		Row:    2,
+		Column: 2,
+	})
+	assert.NotEqual(t, game.Status, constants.GameStatusLost)
+	boardGrid := newGrid(game)
+	minesCount := 0
+	for _, cell := range boardGrid.positions() {
+		if cellAt(game.Board3D, cell).HasMine {
+			minesCount++
+		}
+		if cellAt(game.Board3D, cell).IsRevealed && cellAt(game.Board3D, cell).SourroundedBy == 0 {
+			for _, neighbour := range boardGrid.neighbours(cell) {
+				assert.True(t, cellAt(game.Board3D, neighbour).IsRevealed)
+			}
+		}
+	}
+	assert.Equal(t, minesCount, 6)
+
+	solution := boardSolutionToString(game)
+	assert.Equal(t, strings.Count(solution, "layer "), 3)
+	assert.Equal(t, strings.Count(solution, "\n"), 3+3*4)
+	assert.Equal(t, strings.Count(boardToString(game), "|"), 3+3*4)
+
+	for _, cell := range boardGrid.positions() {
+		if cellAt(game.Board3D, cell).HasMine {
+			cellAt(game.Board3D, cell).Flag = constants.FlagRedFlag
+		}
+	}
+	for _, cell := range boardGrid.positions() {
+		if !cellAt(game.Board3D, cell).HasMine && !cellAt(game.Board3D, cell).IsRevealed {
+			game, _ = gameService.revealCellAt(game, cell)
+		}
+	}
+	assert.Equal(t, game.Status, constants.GameResultWon)
+}
+func TestLivesMode(t *testing.T) {
+	cases := []struct {
+		name               string
+		lives              int
+		mineHits           []int
+		expectedStatus     string
+		expectedLives      int
+		expectedExplosions int
+	}{
+		{
+			name:               "OK/CLASSIC_GAME",
+			lives:              1,
+			mineHits:           []int{0},
+			expectedStatus:     constants.GameStatusLost,
+			expectedLives:      0,
+			expectedExplosions: 1,
+		},
+		{
+			name:               "OK/SURVIVES_EXPLOSION",
+			lives:              2,
+			mineHits:           []int{0},
+			expectedStatus:     constants.GameStatusOnGoing,
+			expectedLives:      1,
+			expectedExplosions: 1,
+		},
+		{
+			name:               "OK/RUNS_OUT_OF_LIVES",
+			lives:              2,
+			mineHits:           []int{0, 2},
+			expectedStatus:     constants.GameStatusLost,
+			expectedLives:      0,
+			expectedExplosions: 2,
+		},
+	}
+
+	gameService := &GameService{
+		Repository: dao.CreateInMemoryContainer(),
+	}
+	livesGrid := grid{layers: 1, columns: 3, rows: 1}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			board := placeMines(livesGrid, 0, NewSeededRandomSource(1))
+			board[0][0][0].HasMine = true
+			board[0][2][0].HasMine = true
+			board = setNeighgoursCount(board, livesGrid)
+			game := &domain.Game{
+				GameID:  1,
+				Rows:    1,
+				Columns: 3,
+				Mines:   2,
+				Status:  constants.GameStatusOnGoing,
+				Board:   board[0],
+				Lives:   c.lives,
+			}
+
+			for _, column := range c.mineHits {
+				game, _ = gameService.RevealCellFloodFill(game, column, 0)
+			}
+
+			assert.Equal(t, game.Status, c.expectedStatus)
+			assert.Equal(t, game.Lives, c.expectedLives)
+			assert.Equal(t, game.Explosions, c.expectedExplosions)
+			assert.Equal(t, game.Board[0][0].Exploded, true)
+		})
+	}
+
+	t.Run("OK/WIN_AFTER_EXPLOSION", func(t *testing.T) {
+		board := placeMines(livesGrid, 0, NewSeededRandomSource(1))
+		board[0][0][0].HasMine = true
+		board[0][2][0].HasMine = true
+		board = setNeighgoursCount(board, livesGrid)
+		board[0][2][0].Flag = constants.FlagRedFlag
+		game := &domain.Game{
+			GameID:  1,
+			Rows:    1,
+			Columns: 3,
+			Mines:   2,
+			Status:  constants.GameStatusOnGoing,
+			Board:   board[0],
+			Lives:   2,
+		}
+
+		game, _ = gameService.RevealCellFloodFill(game, 0, 0)
+		assert.Equal(t, game.Status, constants.GameStatusOnGoing)
+		game, _ = gameService.RevealCellFloodFill(game, 1, 0)
+		assert.Equal(t, game.Status, constants.GameResultWon)
+	})
+
+	newGame, _ := gameService.CreateGame(&domain.NewGameConditionsRequest{
+		UserID:  "test_user",
+		Rows:    3,
+		Columns: 3,
+		Mines:   1,
+	})
+	assert.Equal(t, newGame.Lives, 1)
+}
+func TestRevealExplodedMineAgain(t *testing.T) {
+	seed := int64(7)
+	gameService := &GameService{
+		Repository: dao.CreateInMemoryContainer(),
+	}
+	newGame, _ := gameService.CreateGame(&domain.NewGameConditionsRequest{
+		UserID:  "test_user",
+		Rows:    5,
+		Columns: 5,
+		Mines:   3,
+		Lives:   3,
+		Seed:    &seed,
+	})
+	firstReveal, _ := gameService.RevealCell(&domain.RevealCellRequest{UserID: "test_user", GameID: newGame.GameID, Row: 2, Column: 2})
+	mine := firstReveal.MineLocations[0]
+	mineRequest := &domain.RevealCellRequest{UserID: "test_user", GameID: newGame.GameID, Row: mine.Row, Column: mine.Column}
+	game, err := gameService.RevealCell(mineRequest)
+	assert.Nil(t, err)
+	assert.Equal(t, game.Lives, 2)
+	moves := len(game.Moves)
+
+	_, err = gameService.RevealCell(mineRequest)
+	assert.NotNil(t, err)
+	game, _ = gameService.GetGameByGameID("test_user", newGame.GameID)
+	assert.Equal(t, game.Status, constants.GameStatusOnGoing)
+	assert.Equal(t, game.Lives, 2)
+	assert.Equal(t, game.Explosions, 1)
+	assert.Equal(t, len(game.Moves), moves)
+}
+func TestUndoMove(t *testing.T) {
+	seed := int64(7)
+	cases := []struct {
+		name            string
+		ranked          bool
+		undoRankedGames bool
+		moves           bool
+		expectedError   bool
+	}{
+		{
+			name:  "OK/CASUAL",
+			moves: true,
+		},
+		{
+			name:            "OK/RANKED_UNDO_ENABLED",
+			ranked:          true,
+			undoRankedGames: true,
+			moves:           true,
+		},
+		{
+			name:          "FAIL/RANKED",
+			ranked:        true,
+			moves:         true,
+			expectedError: true,
+		},
+		{
+			name:          "FAIL/NO_MOVES",
+			expectedError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gameService := &GameService{
+				Repository:      dao.CreateInMemoryContainer(),
+				UndoRankedGames: c.undoRankedGames,
+			}
+			gameRequest := &domain.NewGameConditionsRequest{
+				UserID:  "test_user",
+				Rows:    5,
+				Columns: 5,
+				Mines:   3,
+				Ranked:  c.ranked,
+			}
+			if !c.ranked {
+				gameRequest.Seed = &seed
+			}
+			newGame, _ := gameService.CreateGame(gameRequest)
+			if c.moves {
+				gameService.FlagCell(&domain.FlagCellRequest{UserID: "test_user", GameID: newGame.GameID, Flag: constants.FlagRedFlag})
+				gameService.RevealCell(&domain.RevealCellRequest{UserID: "test_user", GameID: newGame.GameID, Row: 2, Column: 2})
+			}
+
+			game, err := gameService.UndoMove("test_user", newGame.GameID)
+
+			if c.expectedError {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, GetRevealedCellsCount(game.Board, game.Columns, game.Rows), 0)
+			assert.Equal(t, game.Board[0][0].Flag, constants.FlagRedFlag)
+			assert.Equal(t, game.FirstClickDone, true)
+			assert.Equal(t, len(game.Moves), 3)
+			assert.Equal(t, game.Moves[2].Action, constants.MoveUndo)
+
+			game, _ = gameService.UndoMove("test_user", newGame.GameID)
+			assert.Equal(t, game.Board[0][0].Flag, "")
+			_, err = gameService.UndoMove("test_user", newGame.GameID)
+			assert.NotNil(t, err)
+		})
+	}
+
+	gameService := &GameService{
+		Repository: dao.CreateInMemoryContainer(),
+	}
+	newGame, _ := gameService.CreateGame(&domain.NewGameConditionsRequest{
+		UserID:  "test_user",
+		Rows:    5,
+		Columns: 5,
+		Mines:   5,
+		Seed:    &seed,
+	})
+	views := make([]*domain.PlayerGame, 0)
+	firstReveal, _ := gameService.RevealCell(&domain.RevealCellRequest{UserID: "test_user", GameID: newGame.GameID, Row: 2, Column: 2})
+	views = append(views, firstReveal.PlayerView(gameService.Now()))
+	mine := firstReveal.MineLocations[0]
+	lostGame, _ := gameService.RevealCell(&domain.RevealCellRequest{UserID: "test_user", GameID: newGame.GameID, Row: mine.Row, Column: mine.Column})
+	assert.Equal(t, lostGame.Status, constants.GameStatusLost)
+
+	// Undoing the mine hit would let the player go on after seeing the whole board
+	_, err := gameService.UndoMove("test_user", newGame.GameID)
+	assert.NotNil(t, err)
+	game, _ := gameService.GetGameByGameID("test_user", newGame.GameID)
+	assert.Equal(t, game.Status, constants.GameStatusLost)
+	_, err = gameService.RevealCell(&domain.RevealCellRequest{UserID: "test_user", GameID: newGame.GameID, Row: 0, Column: 0})
+	assert.NotNil(t, err)
+
+	// The mines were only sent once the game could no longer be played
+	for _, view := range views {
+		for _, column := range view.Board {
+			for _, cell := range column {
+				assert.True(t, cell.IsRevealed || cell.HasMine == nil)
+			}
+		}
+	}
+}
+func TestUndoOnlyMove(t *testing.T) {
+	now := time.Date(2020, 10, 3, 16, 0, 0, 0, time.UTC)
+	start := now
+	gameService := &GameService{
+		Repository: dao.CreateInMemoryContainer(),
+		Clock:      func() time.Time { return now },
+	}
+	newGame, _ := gameService.CreateGame(&domain.NewGameConditionsRequest{
+		UserID:  "test_user",
+		Rows:    5,
+		Columns: 5,
+		Mines:   3,
+	})
+	gameService.FlagCell(&domain.FlagCellRequest{UserID: "test_user", GameID: newGame.GameID, Flag: constants.FlagRedFlag})
+
+	now = start.Add(100 * time.Second)
+	game, err := gameService.UndoMove("test_user", newGame.GameID)
+	assert.Nil(t, err)
+	assert.Equal(t, game.Status, constants.GameStatusOnGoing)
+	assert.Equal(t, game.Board[0][0].Flag, "")
+	assert.Equal(t, game.PlayerView(gameService.Now()).ElapsedSeconds, int64(100))
+
+	// The clock keeps running after the undo
+	now = start.Add(130 * time.Second)
+	game, _ = gameService.GetGameByGameID("test_user", newGame.GameID)
+	assert.Equal(t, game.Status, constants.GameStatusOnGoing)
+	assert.Equal(t, game.PlayerView(gameService.Now()).ElapsedSeconds, int64(130))
+}
+func TestGameReplay(t *testing.T) {
+	seed := int64(7)
+	gameService := &GameService{
+		Repository: dao.CreateInMemoryContainer(),
+	}
+	newGame, _ := gameService.CreateGame(&domain.NewGameConditionsRequest{
+		UserID:  "test_user",
+		Rows:    5,
+		Columns: 5,
+		Mines:   5,
+		Seed:    &seed,
+	})
+	game, _ := gameService.RevealCell(&domain.RevealCellRequest{UserID: "test_user", GameID: newGame.GameID, Row: 2, Column: 2})
+	revealedAfterFirstMove := GetRevealedCellsCount(game.Board, game.Columns, game.Rows)
+
+	_, err := gameService.GetReplay("test_user", newGame.GameID, nil)
+	assert.NotNil(t, err)
+
+	mine := game.MineLocations[0]
+	gameService.FlagCell(&domain.FlagCellRequest{UserID: "test_user", GameID: newGame.GameID, Row: mine.Row, Column: mine.Column, Flag: constants.FlagRedFlag})
+	gameService.FlagCell(&domain.FlagCellRequest{UserID: "test_user", GameID: newGame.GameID, Row: mine.Row, Column: mine.Column, Flag: constants.FlagRedFlag})
+	game, _ = gameService.RevealCell(&domain.RevealCellRequest{UserID: "test_user", GameID: newGame.GameID, Row: mine.Row, Column: mine.Column})
+	assert.Equal(t, game.Status, constants.GameStatusLost)
+
+	cases := []struct {
+		name             string
+		move             *int
+		expectedStatus   string
+		expectedRevealed int
+		expectedFlag     string
+		expectedError    bool
+	}{
+		{
+			name:             "OK/FULL_REPLAY",
+			expectedStatus:   constants.GameStatusLost,
+			expectedRevealed: revealedAfterFirstMove + 1,
+		},
+		{
+			name:           "OK/BEFORE_FIRST_MOVE",
+			move:           new(int),
+			expectedStatus: constants.GameStatusCreated,
+		},
+		{
+			name:             "OK/AFTER_FLAG",
+			move:             func() *int { move := 2; return &move }(),
+			expectedStatus:   constants.GameStatusOnGoing,
+			expectedRevealed: revealedAfterFirstMove,
+			expectedFlag:     constants.FlagRedFlag,
+		},
+		{
+			name:          "FAIL/MOVE_OUT_OF_RANGE",
+			move:          func() *int { move := 5; return &move }(),
+			expectedError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			replay, err := gameService.GetReplay("test_user", newGame.GameID, c.move)
+
+			if c.expectedError {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, replay.Status, c.expectedStatus)
+			assert.Equal(t, replay.TotalMoves, 4)
+			assert.Equal(t, len(replay.Moves), 4)
+			assert.Equal(t, replay.Board[mine.Column][mine.Row].Flag, c.expectedFlag)
+			assert.Equal(t, *replay.Board[mine.Column][mine.Row].HasMine, true)
+			revealed := 0
+			for _, column := range replay.Board {
+				for _, cell := range column {
+					if cell.IsRevealed {
+						revealed++
+					}
+				}
+			}
+			assert.Equal(t, revealed, c.expectedRevealed)
+		})
+	}
+}
+func TestPauseAndResume(t *testing.T) {
+	gameService := &GameService{
+		Repository: dao.CreateInMemoryContainer(),
+	}
+	newGame, _ := gameService.CreateGame(&domain.NewGameConditionsRequest{
+		UserID:  "test_user",
+		Rows:    5,
+		Columns: 5,
+		Mines:   3,
+	})
+
+	_, err := gameService.PauseGame("test_user", newGame.GameID)
+	assert.NotNil(t, err)
+	gameService.RevealCell(&domain.RevealCellRequest{UserID: "test_user", GameID: newGame.GameID})
+
+	game, err := gameService.PauseGame("test_user", newGame.GameID)
+	assert.Nil(t, err)
+	assert.Equal(t, game.Status, constants.GameStatusPaused)
+	elapsed := game.Elapsed(time.Now())
+	assert.Equal(t, game.Elapsed(time.Now().Add(time.Hour)), elapsed)
+
+	_, err = gameService.RevealCell(&domain.RevealCellRequest{UserID: "test_user", GameID: newGame.GameID})
+	assert.NotNil(t, err)
+	_, err = gameService.FlagCell(&domain.FlagCellRequest{UserID: "test_user", GameID: newGame.GameID, Flag: constants.FlagRedFlag})
+	assert.NotNil(t, err)
+	_, err = gameService.PauseGame("test_user", newGame.GameID)
+	assert.NotNil(t, err)
+
+	game, err = gameService.ResumeGame("test_user", newGame.GameID)
+	assert.Nil(t, err)
+	assert.Equal(t, game.Status, constants.GameStatusOnGoing)
+	assert.True(t, game.Elapsed(time.Now().Add(time.Hour)) >= elapsed+time.Hour)
+	_, err = gameService.ResumeGame("test_user", newGame.GameID)
+	assert.NotNil(t, err)
+
+	_, err = gameService.FlagCell(&domain.FlagCellRequest{UserID: "test_user", GameID: newGame.GameID, Row: 4, Column: 4, Flag: constants.FlagRedFlag})
+	assert.Nil(t, err)
+}
+func TestTimedGame(t *testing.T) {
+	now := time.Date(2020, 10, 3, 16, 0, 0, 0, time.UTC)
+	start := now
+	gameService := &GameService{
+		Repository: dao.CreateInMemoryContainer(),
+		Clock:      func() time.Time { return now },
+	}
+	timedGame, _ := gameService.CreateGame(&domain.NewGameConditionsRequest{
+		UserID:    "test_user",
+		Rows:      5,
+		Columns:   5,
+		Mines:     3,
+		TimeLimit: 60,
+	})
+	untimedGame, _ := gameService.CreateGame(&domain.NewGameConditionsRequest{
+		UserID:  "test_user",
+		Rows:    5,
+		Columns: 5,
+		Mines:   3,
+	})
+
+	now = start.Add(30 * time.Second)
+	game, err := gameService.RevealCell(&domain.RevealCellRequest{UserID: "test_user", GameID: timedGame.GameID, Row: 2, Column: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, game.Status, constants.GameStatusOnGoing)
+	_, err = gameService.PauseGame("test_user", timedGame.GameID)
+	assert.NotNil(t, err)
+
+	// The view counts the time with the clock of the service, like the status
+	now = start.Add(45 * time.Second)
+	view := game.PlayerView(gameService.Now())
+	assert.Equal(t, view.Status, constants.GameStatusOnGoing)
+	assert.Equal(t, view.ElapsedSeconds, int64(15))
+
+	now = start.Add(61 * time.Second)
+	game, _ = gameService.GetGameByGameID("test_user", timedGame.GameID)
+	assert.Equal(t, game.Status, constants.GameStatusTimedOut)
+	assert.Equal(t, game.Finish, start.Add(60*time.Second))
+	assert.Equal(t, game.Elapsed(now), 30*time.Second)
+	stored, _ := gameService.Repository.Get("test_user")
+	assert.Equal(t, stored.Games[0].Status, constants.GameStatusTimedOut)
+
+	_, err = gameService.RevealCell(&domain.RevealCellRequest{UserID: "test_user", GameID: timedGame.GameID})
+	assert.NotNil(t, err)
+	_, err = gameService.FlagCell(&domain.FlagCellRequest{UserID: "test_user", GameID: timedGame.GameID, Flag: constants.FlagRedFlag})
+	assert.NotNil(t, err)
+
+	game, _ = gameService.GetGameByGameID("test_user", untimedGame.GameID)
+	assert.Equal(t, game.Status, constants.GameStatusCreated)
+}
+func TestGameTransitions(t *testing.T) {
+	cases := []struct {
+		name           string
+		status         string
+		event          string
+		expectedStatus string
+		expectedError  bool
+	}{
+		{
+			name:           "OK/FIRST_MOVE",
+			status:         constants.GameStatusCreated,
+			event:          eventPlay,
+			expectedStatus: constants.GameStatusOnGoing,
+		},
+		{
+			name:           "OK/PAUSE",
+			status:         constants.GameStatusOnGoing,
+			event:          eventPause,
+			expectedStatus: constants.GameStatusPaused,
+		},
+		{
+			name:           "OK/ABANDON_PAUSED",
+			status:         constants.GameStatusPaused,
+			event:          eventAbandon,
+			expectedStatus: constants.GameStatusAbandoned,
+		},
+		{
+			name:          "FAIL/PLAY_PAUSED",
+			status:        constants.GameStatusPaused,
+			event:         eventPlay,
+			expectedError: true,
+		},
+		{
+			name:          "FAIL/PLAY_WON",
+			status:        constants.GameResultWon,
+			event:         eventPlay,
+			expectedError: true,
+		},
+		{
+			name:          "FAIL/UNDO_LOST",
+			status:        constants.GameStatusLost,
+			event:         eventUndo,
+			expectedError: true,
+		},
+		{
+			name:          "FAIL/UNDO_TIMED_OUT",
+			status:        constants.GameStatusTimedOut,
+			event:         eventUndo,
+			expectedError: true,
+		},
+		{
+			name:          "FAIL/RESUME_ON_GOING",
+			status:        constants.GameStatusOnGoing,
+			event:         eventResume,
+			expectedError: true,
+		},
+	}
+
+	now := time.Date(2020, 10, 3, 16, 0, 0, 0, time.UTC)
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			game := &domain.Game{Status: c.status, Start: now}
+
+			err := applyEvent(game, c.event, now)
+
+			if c.expectedError {
+				assert.NotNil(t, err)
+				assert.Equal(t, game.Status, c.status)
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, game.Status, c.expectedStatus)
+				assert.Equal(t, game.Finish.IsZero(), !game.IsOver())
+			}
+		})
+	}
+
+	gameService := &GameService{
+		Repository: dao.CreateInMemoryContainer(),
+	}
+	gameService.Repository.Insert(&domain.UserGame{
+		UserID: "test_user",
+		Games:  []*domain.Game{{GameID: 1, Status: constants.GameStatusLose}},
+	})
+	game, _ := gameService.GetGameByGameID("test_user", 1)
+	assert.Equal(t, game.Status, constants.GameStatusLost)
+	stored, _ := gameService.Repository.Get("test_user")
+	assert.Equal(t, stored.Games[0].Status, constants.GameStatusLost)
+}
+func TestAbandonGame(t *testing.T) {
+	now := time.Date(2020, 10, 3, 16, 0, 0, 0, time.UTC)
+	gameService := &GameService{
+		Repository: dao.CreateInMemoryContainer(),
+		Clock:      func() time.Time { return now },
+	}
+	newGame, _ := gameService.CreateGame(&domain.NewGameConditionsRequest{
+		UserID:  "test_user",
+		Rows:    5,
+		Columns: 5,
+		Mines:   3,
+	})
+	gameService.RevealCell(&domain.RevealCellRequest{UserID: "test_user", GameID: newGame.GameID, Row: 2, Column: 2})
+
+	now = now.Add(time.Minute)
+	game, err := gameService.AbandonGame("test_user", newGame.GameID)
+	assert.Nil(t, err)
+	assert.Equal(t, game.Status, constants.GameStatusAbandoned)
+	assert.Equal(t, game.Finish, now)
+	for _, column := range game.PlayerView(gameService.Now()).Board {
+		for _, cell := range column {
+			assert.NotNil(t, cell.HasMine)
+		}
+	}
+
+	_, err = gameService.AbandonGame("test_user", newGame.GameID)
+	assert.NotNil(t, err)
+	_, err = gameService.RevealCell(&domain.RevealCellRequest{UserID: "test_user", GameID: newGame.GameID})
+	assert.NotNil(t, err)
+	game, err = gameService.AbandonGame("test_user", 1)
+	assert.Nil(t, err)
+	assert.Nil(t, game)
+}
+func TestUserStats(t *testing.T) {
+	start := time.Date(2020, 10, 3, 16, 0, 0, 0, time.UTC)
+	gameService := &GameService{
+		Repository: dao.CreateInMemoryContainer(),
+		Clock:      func() time.Time { return start.Add(time.Hour) },
+	}
+	gameService.Repository.Insert(&domain.UserGame{
+		UserID: "test_user",
+		Games: []*domain.Game{
+			{GameID: 1, Status: constants.GameResultWon, Start: start, ActiveTime: 90 * time.Second},
+			{GameID: 2, Status: constants.GameResultWon, Start: start, ActiveTime: 40 * time.Second},
+			{GameID: 3, Status: constants.GameStatusLost, Start: start, ActiveTime: 10 * time.Second},
+			{GameID: 4, Status: constants.GameStatusAbandoned, Start: start, ActiveTime: 20 * time.Second},
+			{GameID: 5, Status: constants.GameStatusTimedOut, Start: start, ActiveTime: 30 * time.Second},
+			{GameID: 6, Status: constants.GameStatusCreated, Start: start},
+		},
+	})
+
+	stats, err := gameService.GetUserStats("test_user")
+	assert.Nil(t, err)
+	assert.Equal(t, stats.GamesPlayed, 6)
+	assert.Equal(t, stats.Won, 2)
+	assert.Equal(t, stats.Lost, 1)
+	assert.Equal(t, stats.Abandoned, 1)
+	assert.Equal(t, stats.TimedOut, 1)
+	assert.Equal(t, stats.InProgress, 1)
+	assert.Equal(t, *stats.BestTimeSeconds, int64(40))
+	assert.Equal(t, stats.TotalPlayedSeconds, int64(190))
+
+	stats, err = gameService.GetUserStats("unknown_user")
+	assert.Nil(t, err)
+	assert.Nil(t, stats)
+}
+func TestListGames(t *testing.T) {
+	start := time.Date(2020, 10, 3, 16, 0, 0, 0, time.UTC)
+	gameService := &GameService{
+		Repository: dao.CreateInMemoryContainer(),
+		Clock:      func() time.Time { return start.Add(time.Hour) },
+	}
+	gameService.Repository.Insert(&domain.UserGame{
+		UserID: "test_user",
+		Games: []*domain.Game{
+			{GameID: 1, Start: start, Status: constants.GameStatusLose},
+			{GameID: 2, Start: start, Status: constants.GameStatusOnGoing, TimeLimit: 60},
+			{GameID: 3, Start: start, Status: constants.GameStatusOnGoing},
+		},
+	})
+
+	page, err := gameService.ListAllGames(domain.GameQuery{Statuses: []string{constants.GameStatusLost, constants.GameStatusOnGoing}})
+	assert.Nil(t, err)
+	assert.Equal(t, page.Total, 3)
+	assert.Equal(t, page.PageSize, constants.DefaultPageSize)
+	statuses := make(map[int64]string)
+	for _, game := range page.Games {
+		statuses[game.GameID] = game.Status
+	}
+	assert.Equal(t, statuses, map[int64]string{
+		1: constants.GameStatusLost,
+		2: constants.GameStatusTimedOut,
+		3: constants.GameStatusOnGoing,
+	})
+	stored, _ := gameService.Repository.Get("test_user")
+	assert.Equal(t, stored.Games[1].Status, constants.GameStatusTimedOut)
+
+	page, err = gameService.ListUserGames(domain.GameQuery{UserID: "test_user", Statuses: []string{constants.GameStatusTimedOut}})
+	assert.Nil(t, err)
+	assert.Equal(t, page.Total, 1)
+	assert.Equal(t, page.Games[0].GameID, int64(2))
+
+	page, err = gameService.ListUserGames(domain.GameQuery{UserID: "unknown_user"})
+	assert.Nil(t, err)
+	assert.Nil(t, page)
+
+	// Listing a page doesn't load all the games of the user, unless a listed game has to be refreshed
+	repository := &countingRepository{GameRepository: dao.CreateInMemoryContainer()}
+	gameService = &GameService{
+		Repository: repository,
+		Clock:      func() time.Time { return start.Add(time.Hour) },
+	}
+	repository.Insert(&domain.UserGame{
+		UserID: "test_user",
+		Games: []*domain.Game{
+			{GameID: 1, Start: start, Status: constants.GameStatusOnGoing},
+			{GameID: 2, Start: start.Add(time.Minute), Status: constants.GameStatusOnGoing, TimeLimit: 60},
+		},
+	})
+	page, _ = gameService.ListUserGames(domain.GameQuery{UserID: "test_user", PageSize: 1, Ascending: true})
+	assert.Equal(t, page.Games[0].GameID, int64(1))
+	page, _ = gameService.ListUserGames(domain.GameQuery{UserID: "test_user", Statuses: []string{constants.GameResultWon}})
+	assert.Equal(t, page.Total, 0)
+	assert.Empty(t, page.Games)
+	assert.Equal(t, repository.gets, 0)
+
+	page, _ = gameService.ListUserGames(domain.GameQuery{UserID: "test_user", Page: 2, PageSize: 1, Ascending: true})
+	assert.Equal(t, page.Games[0].Status, constants.GameStatusTimedOut)
+	assert.Equal(t, repository.gets, 1)
+	stored, _ = repository.GameRepository.Get("test_user")
+	assert.Equal(t, stored.Games[1].Status, constants.GameStatusTimedOut)
+}
+
+// countingRepository counts the times all the games of a user are loaded
+type countingRepository struct {
+	GameRepository
+	gets int
+}
+
+func (cr *countingRepository) Get(userID string) (*domain.UserGame, error) {
+	cr.gets++
+	return cr.GameRepository.Get(userID)
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"github.com/mercadolibre/minesweeper/constants"
+	"github.com/mercadolibre/minesweeper/domain"
+)
+
+// grid knows the shape of a board and which of its cells are next to each other.
+// Hexagonal boards use offset coordinates: the odd rows are shifted half a cell to
+// the right, so the neighbours above and below depend on the parity of the row.
+// Three dimensional boards stack layers, every cell touching the 26 cells around it.
+// Wrapping boards join their opposite edges, so every cell has all its neighbours
+type grid struct {
+	topology string
+	layers   int
+	columns  int
+	rows     int
+	wrap     bool
+}
+
+// position is the location of a cell, the layer is always 0 in flat boards
+type position struct {
+	layer  int
+	column int
+	row    int
+}
+
+var squareOffsets = []position{
+	{0, -1, -1}, {0, -1, 0}, {0, -1, 1}, {0, 0, -1}, {0, 0, 1}, {0, 1, -1}, {0, 1, 0}, {0, 1, 1},
+}
+
+var hexEvenRowOffsets = []position{{0, -1, 0}, {0, 1, 0}, {0, -1, -1}, {0, 0, -1}, {0, -1, 1}, {0, 0, 1}}
+
+var hexOddRowOffsets = []position{{0, -1, 0}, {0, 1, 0}, {0, 0, -1}, {0, 1, -1}, {0, 0, 1}, {0, 1, 1}}
+
+var cubeOffsets = func() []position {
+	offsets := make([]position, 0, 26)
+	for layer := -1; layer <= 1; layer++ {
+		for column := -1; column <= 1; column++ {
+			for row := -1; row <= 1; row++ {
+				if layer != 0 || column != 0 || row != 0 {
+					offsets = append(offsets, position{layer, column, row})
+				}
+			}
+		}
+	}
+	return offsets
+}()
+
+func newGrid(game *domain.Game) grid {
+	layers := 1
+	if game.Topology == constants.Topology3D {
+		layers = game.Layers
+	}
+	return grid{
+		topology: game.Topology,
+		layers:   layers,
+		columns:  game.Columns,
+		rows:     game.Rows,
+		wrap:     game.Wrap,
+	}
+}
+
+// contains tells if the position is inside the board
+func (g grid) contains(p position) bool {
+	return p.layer >= 0 && p.layer < g.layers && p.column >= 0 && p.column < g.columns && p.row >= 0 && p.row < g.rows
+}
+
+// positions returns every position of the board
+func (g grid) positions() []position {
+	positions := make([]position, 0, g.layers*g.columns*g.rows)
+	for layer := 0; layer < g.layers; layer++ {
+		for column := 0; column < g.columns; column++ {
+			for row := 0; row < g.rows; row++ {
+				positions = append(positions, position{layer, column, row})
+			}
+		}
+	}
+	return positions
+}
+
+// neighbours returns the cells next to the given one that are inside the board
+func (g grid) neighbours(p position) []position {
+
+	offsets := squareOffsets
+	if g.topology == constants.TopologyHex {
+		if p.row%2 == 0 {
+			offsets = hexEvenRowOffsets
+		} else {
+			offsets = hexOddRowOffsets
+		}
+	} else if g.topology == constants.Topology3D {
+		offsets = cubeOffsets
+	}
+
+	neighbours := make([]position, 0, len(offsets))
+	for _, offset := range offsets {
+		neighbour := position{p.layer + offset.layer, p.column + offset.column, p.row + offset.row}
+		if g.wrap {
+			neighbour.layer = (neighbour.layer + g.layers) % g.layers
+			neighbour.column = (neighbour.column + g.columns) % g.columns
+			neighbour.row = (neighbour.row + g.rows) % g.rows
+			if neighbour == p || containsPosition(neighbours, neighbour) {
+				continue
+			}
+		} else if !g.contains(neighbour) {
+			continue
+		}
+		neighbours = append(neighbours, neighbour)
+	}
+	return neighbours
+}
+
+// area returns the cell followed by its neighbours
+func (g grid) area(p position) []position {
+	return append([]position{p}, g.neighbours(p)...)
+}
+
+// newBoard returns an empty board with the shape of the grid
+func (g grid) newBoard() [][][]domain.Cell {
+	board := make([][][]domain.Cell, g.layers)
+	for layer := range board {
+		board[layer] = make([][]domain.Cell, g.columns)
+		for column := range board[layer] {
+			board[layer][column] = make([]domain.Cell, g.rows)
+		}
+	}
+	return board
+}
+
+func containsPosition(positions []position, p position) bool {
+	for _, candidate := range positions {
+		if candidate == p {
+			return true
+		}
+	}
+	return false
+}
+
+// layersOf returns the board of the game as a list of layers. Flat boards have a
+// single layer that shares its cells with game.Board
+func layersOf(game *domain.Game) [][][]domain.Cell {
+	if game.Topology == constants.Topology3D {
+		return game.Board3D
+	}
+	return [][][]domain.Cell{game.Board}
+}
+
+// setLayers stores the board in the field of the game matching its shape
+func setLayers(game *domain.Game, board [][][]domain.Cell) {
+	if game.Topology == constants.Topology3D {
+		game.Board3D = board
+	} else {
+		game.Board = board[0]
+	}
+}
+
+func cellAt(board [][][]domain.Cell, p position) *domain.Cell {
+	return &board[p.layer][p.column][p.row]
+}
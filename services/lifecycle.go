@@ -0,0 +1,111 @@
+package services
+
+import (
+	"time"
+
+	"github.com/mercadolibre/minesweeper/constants"
+	"github.com/mercadolibre/minesweeper/domain"
+	"github.com/mercadolibre/minesweeper/errors"
+)
+
+// Events that move a game through its lifecycle
+const (
+	eventPlay    = "play"
+	eventPause   = "pause"
+	eventResume  = "resume"
+	eventUndo    = "undo"
+	eventWin     = "win"
+	eventLose    = "lose"
+	eventAbandon = "abandon"
+	eventTimeOut = "time_out"
+)
+
+// gameTransitions is the lifecycle of a game, the status each event leads to from every status.
+// Games that are over can't leave their status: a lost game shows its whole board, so undoing
+// the mine hit would let the player go on knowing where every mine is
+var gameTransitions = map[string]map[string]string{
+	constants.GameStatusCreated: {
+		eventPlay:    constants.GameStatusOnGoing,
+		eventAbandon: constants.GameStatusAbandoned,
+		eventTimeOut: constants.GameStatusTimedOut,
+	},
+	constants.GameStatusOnGoing: {
+		eventPlay:    constants.GameStatusOnGoing,
+		eventPause:   constants.GameStatusPaused,
+		eventUndo:    constants.GameStatusOnGoing,
+		eventWin:     constants.GameResultWon,
+		eventLose:    constants.GameStatusLost,
+		eventAbandon: constants.GameStatusAbandoned,
+		eventTimeOut: constants.GameStatusTimedOut,
+	},
+	constants.GameStatusPaused: {
+		eventResume:  constants.GameStatusOnGoing,
+		eventAbandon: constants.GameStatusAbandoned,
+	},
+}
+
+// legacyStatuses maps the statuses stored by older versions to the current ones
+var legacyStatuses = map[string]string{
+	constants.GameStatusLose: constants.GameStatusLost,
+}
+
+// nextStatus returns the status the event leads the game to, or an illegal_transition error
+func nextStatus(game *domain.Game, event string) (string, error) {
+
+	status, ok := gameTransitions[game.Status][event]
+	if !ok {
+		return "", &errors.ApiError{
+			Message:  "can't " + event + " a game that is " + game.Status,
+			ErrorStr: "illegal_transition",
+		}
+	}
+	return status, nil
+}
+
+// applyEvent moves the game to the status the event leads to, keeping its active time:
+// the time runs from the first move until the game is paused or over
+func applyEvent(game *domain.Game, event string, now time.Time) error {
+
+	status, err := nextStatus(game, event)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case game.Status != constants.GameStatusOnGoing && status == constants.GameStatusOnGoing:
+		game.ResumedAt = now
+	case game.Status == constants.GameStatusOnGoing && status != constants.GameStatusOnGoing:
+		game.ActiveTime = game.Elapsed(now)
+	}
+	game.Status = status
+	if game.IsOver() {
+		game.Finish = now
+	}
+	return nil
+}
+
+// migrateStatus replaces the legacy status of the game, returning if it changed
+func migrateStatus(game *domain.Game) bool {
+
+	status, ok := legacyStatuses[game.Status]
+	if !ok {
+		return false
+	}
+	game.Status = status
+	return true
+}
+
+// withLegacyStatuses adds to the statuses the legacy ones that are migrated to them, so
+// the games not read since the migration are found as well
+func withLegacyStatuses(statuses []string) []string {
+
+	all := append([]string{}, statuses...)
+	for legacy, status := range legacyStatuses {
+		for _, wanted := range statuses {
+			if wanted == status {
+				all = append(all, legacy)
+			}
+		}
+	}
+	return all
+}
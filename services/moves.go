@@ -0,0 +1,199 @@
+package services
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/mercadolibre/minesweeper/constants"
+	"github.com/mercadolibre/minesweeper/domain"
+	"github.com/mercadolibre/minesweeper/errors"
+)
+
+// UndoMove rolls back the last move of an on going game that was not undone yet. The undo is
+// appended to the move log and the board is rebuilt replaying the remaining moves
+func (gs *GameService) UndoMove(userID string, gameID int64) (*domain.Game, error) {
+	userGame, err := gs.GetGamesByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if userGame == nil {
+		return nil, nil
+	}
+
+	gameIndex := getGameIndex(gameID, userGame)
+	if gameIndex == -1 {
+		return nil, nil
+	}
+
+	game := userGame.Games[gameIndex]
+	if !game.UndoEnabled {
+		return nil, &errors.ApiError{
+			Message:  "undo is disabled for this game",
+			ErrorStr: "undo_disabled",
+		}
+	}
+
+	status, undoErr := nextStatus(game, eventUndo)
+	if undoErr != nil {
+		return nil, undoErr
+	}
+
+	moves := effectiveMoves(game.Moves)
+	if len(moves) == 0 {
+		return nil, &errors.ApiError{
+			Message:  "there are no moves to undo",
+			ErrorStr: "no_moves_to_undo",
+		}
+	}
+
+	rebuiltGame, err := gs.replayMoves(game, moves[:len(moves)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	// The game was started and its clock keeps running, even if no move is left
+	now := gs.Now()
+	rebuiltGame.Status = status
+	rebuiltGame.ActiveTime = game.Elapsed(now)
+	rebuiltGame.ResumedAt = now
+	rebuiltGame.Moves = append(game.Moves, domain.Move{
+		Number: len(game.Moves) + 1,
+		Action: constants.MoveUndo,
+		Time:   now,
+	})
+
+	userGame.Games[gameIndex] = rebuiltGame
+	updateErr := gs.Repository.UpdateGame(userID, rebuiltGame)
+	if updateErr != nil {
+		return nil, updateErr
+	}
+	return rebuiltGame, nil
+}
+
+// GetReplay rebuilds a finished game after the given number of moves of its log, or after
+// all of them when move is nil
+func (gs *GameService) GetReplay(userID string, gameID int64, move *int) (*domain.GameReplay, error) {
+
+	game, err := gs.GetGameByGameID(userID, gameID)
+	if err != nil {
+		return nil, err
+	}
+	if game == nil {
+		return nil, nil
+	}
+
+	if !game.IsOver() {
+		return nil, &errors.ApiError{
+			Message:  "only finished games can be replayed",
+			ErrorStr: "game_not_over",
+		}
+	}
+
+	if game.MineLocations == nil {
+		return nil, &errors.ApiError{
+			Message:  "the game has no move history",
+			ErrorStr: "replay_not_available",
+		}
+	}
+
+	replayMove := len(game.Moves)
+	if move != nil {
+		replayMove = *move
+	}
+	if replayMove < 0 || replayMove > len(game.Moves) {
+		return nil, &errors.ApiError{
+			Message:  "move must be between 0 and " + strconv.Itoa(len(game.Moves)),
+			ErrorStr: "move_out_of_range",
+		}
+	}
+
+	replayedGame, err := gs.replayMoves(game, effectiveMoves(game.Moves[:replayMove]))
+	if err != nil {
+		return nil, err
+	}
+	return replayedGame.ReplayView(replayMove, game.Moves), nil
+}
+
+// recordMove appends a move to the log of the game
+func (gs *GameService) recordMove(game *domain.Game, action string, p position, flag string) {
+	game.Moves = append(game.Moves, domain.Move{
+		Number: len(game.Moves) + 1,
+		Action: action,
+		Layer:  p.layer,
+		Column: p.column,
+		Row:    p.row,
+		Flag:   flag,
+		Time:   gs.Now(),
+	})
+}
+
+// effectiveMoves returns the moves of the log that were not undone, without the undo moves
+func effectiveMoves(moves []domain.Move) []domain.Move {
+
+	effective := make([]domain.Move, 0, len(moves))
+	for _, move := range moves {
+		if move.Action != constants.MoveUndo {
+			effective = append(effective, move)
+		} else if len(effective) > 0 {
+			effective = effective[:len(effective)-1]
+		}
+	}
+	return effective
+}
+
+// replayMoves returns a copy of the game whose board is rebuilt from its mine locations
+// applying the given moves. The first click protection is not run again, once the first
+// reveal settles the mines they don't move, not even if that reveal is undone
+func (gs *GameService) replayMoves(game *domain.Game, moves []domain.Move) (*domain.Game, error) {
+
+	replayedGame := *game
+	boardGrid := newGrid(game)
+	board := boardGrid.newBoard()
+	for _, location := range game.MineLocations {
+		cellAt(board, position{location.Layer, location.Column, location.Row}).HasMine = true
+	}
+	setLayers(&replayedGame, setNeighgoursCount(board, boardGrid))
+
+	replayedGame.Status = constants.GameStatusCreated
+	replayedGame.Finish = time.Time{}
+	replayedGame.Lives = game.MaxLives
+	replayedGame.Explosions = 0
+
+	for _, move := range moves {
+		var err error
+		p := position{move.Layer, move.Column, move.Row}
+		switch move.Action {
+		case constants.MoveReveal:
+			// Logs recorded before repeated reveals were rejected may reveal a cell again
+			if cellAt(layersOf(&replayedGame), p).IsRevealed {
+				continue
+			}
+			_, err = gs.revealCellAt(&replayedGame, p)
+		case constants.MoveChord:
+			_, err = gs.chordCellAt(&replayedGame, p)
+		case constants.MoveFlag:
+			err = gs.flagCellAt(&replayedGame, p, move.Flag)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if replayedGame.IsOver() {
+			replayedGame.Finish = move.Time
+		}
+	}
+	replayedGame.ActiveTime = game.ActiveTime
+	replayedGame.ResumedAt = game.ResumedAt
+	return &replayedGame, nil
+}
+
+// mineLocations returns the location of every mine of the board
+func mineLocations(board [][][]domain.Cell, boardGrid grid) []domain.CellLocation {
+
+	locations := make([]domain.CellLocation, 0)
+	for _, p := range boardGrid.positions() {
+		if cellAt(board, p).HasMine {
+			locations = append(locations, domain.CellLocation{Layer: p.layer, Column: p.column, Row: p.row})
+		}
+	}
+	return locations
+}
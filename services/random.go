@@ -0,0 +1,86 @@
+package services
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"math/big"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/mercadolibre/minesweeper/domain"
+)
+
+// RandomSource is the source of randomness used to lay out the mines of a single game.
+// A new source is created every time a board is generated, so it doesn't need to be safe
+// for concurrent use
+type RandomSource interface {
+	Intn(n int) int
+}
+
+// RandomSourceFactory creates the random source of a game from its seed
+type RandomSourceFactory func(seed int64) RandomSource
+
+// NewSeededRandomSource returns a math/rand source of its own, the same seed always
+// generates the same sequence
+func NewSeededRandomSource(seed int64) RandomSource {
+	return rand.New(rand.NewSource(seed))
+}
+
+// NewCryptoRandomSource returns a source backed by crypto/rand, it ignores the seed
+// so the boards it generates can't be predicted nor reproduced
+func NewCryptoRandomSource(seed int64) RandomSource {
+	return cryptoRandomSource{}
+}
+
+type cryptoRandomSource struct{}
+
+func (cryptoRandomSource) Intn(n int) int {
+	value, err := cryptorand.Int(cryptorand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		panic("crypto/rand is not available: " + err.Error())
+	}
+	return int(value.Int64())
+}
+
+// randomSource returns a new random source for the game, ranked games use the crypto one
+func (gs *GameService) randomSource(game *domain.Game) RandomSource {
+
+	if game.Ranked {
+		if gs.NewRankedRandomSource != nil {
+			return gs.NewRankedRandomSource(game.Seed)
+		}
+		return NewCryptoRandomSource(game.Seed)
+	}
+	if gs.NewRandomSource != nil {
+		return gs.NewRandomSource(game.Seed)
+	}
+	return NewSeededRandomSource(game.Seed)
+}
+
+var lastGameID int64
+
+// generateUniqueID returns the current time in microseconds, or the following ID
+// if another game already took it
+func generateUniqueID() int64 {
+	for {
+		last := atomic.LoadInt64(&lastGameID)
+		gameID := time.Now().UnixNano() / int64(time.Microsecond)
+		if gameID <= last {
+			gameID = last + 1
+		}
+		if atomic.CompareAndSwapInt64(&lastGameID, last, gameID) {
+			return gameID
+		}
+	}
+}
+
+// generateSeed returns an unpredictable seed, so the board can't be guessed from the game data
+func generateSeed() int64 {
+	var seed int64
+	err := binary.Read(cryptorand.Reader, binary.LittleEndian, &seed)
+	if err != nil {
+		return time.Now().UnixNano()
+	}
+	return seed
+}
@@ -0,0 +1,18 @@
+package services
+
+import "github.com/mercadolibre/minesweeper/domain"
+
+// GameRepository stores the games of every user. Get returns nil when the user has no games,
+// Insert replaces all the games of a user while InsertGame and UpdateGame only write the given
+// game. The deletes report whether anything was removed
+type GameRepository interface {
+	Get(userID string) (*domain.UserGame, error)
+	GetAll() ([]*domain.UserGame, error)
+	Find(query domain.GameQuery) ([]*domain.Game, int, error)
+	Insert(userGame *domain.UserGame) error
+	InsertGame(userID string, game *domain.Game) error
+	UpdateGame(userID string, game *domain.Game) error
+	Delete(userID string) (bool, error)
+	DeleteGame(userID string, gameID int64) (bool, error)
+	DeleteAll() error
+}
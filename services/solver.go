@@ -0,0 +1,288 @@
+package services
+
+import (
+	"github.com/mercadolibre/minesweeper/domain"
+)
+
+// noGuessMaxAttempts is the number of layouts tried before giving up on a no guess board
+const noGuessMaxAttempts = 500
+
+// noGuessMaxWork bounds the work of the search of a no guess board, counted in cells laid out
+// and visited by the solver, about a second and a half of search. It keeps big boards from
+// taking too long like a timeout would, but the same seed gives up at the same layout on every
+// machine, so the boards are reproducible
+const noGuessMaxWork = 10000000
+
+// generateNoGuessBoard lays out the mines of the game again, keeping the first revealed cell
+// and its neighbours free, until the solver can clear the board from that cell without guessing.
+// It returns false, leaving the board untouched, if no layout was found within the attempts or
+// the work
+func generateNoGuessBoard(game *domain.Game, p position, random RandomSource, maxWork int) bool {
+
+	boardGrid := newGrid(game)
+	safeCells := boardGrid.area(p)
+	if game.Mines > len(boardGrid.positions())-len(safeCells) {
+		return false
+	}
+
+	previousBoard := layersOf(game)
+	work := maxWork
+	for attempt := 0; attempt < noGuessMaxAttempts && work > 0; attempt++ {
+		board := placeMinesAvoiding(boardGrid, game.Mines, safeCells, random)
+		board = setNeighgoursCount(board, boardGrid)
+		work -= len(boardGrid.positions())
+		if isSolvableWithoutGuessing(board, boardGrid, game.Mines, p, &work) {
+			for _, cell := range boardGrid.positions() {
+				cellAt(board, cell).Flag = cellAt(previousBoard, cell).Flag
+			}
+			setLayers(game, board)
+			return true
+		}
+	}
+	return false
+}
+
+// placeMinesAvoiding places the mines at random leaving the safe cells free
+func placeMinesAvoiding(boardGrid grid, mines int, safeCells []position, random RandomSource) [][][]domain.Cell {
+
+	isSafe := make(map[position]bool, len(safeCells))
+	for _, cell := range safeCells {
+		isSafe[cell] = true
+	}
+
+	newBoard := boardGrid.newBoard()
+	freeCells := make([]position, 0, len(boardGrid.positions()))
+	for _, cell := range boardGrid.positions() {
+		if !isSafe[cell] {
+			freeCells = append(freeCells, cell)
+		}
+	}
+
+	for minesLeftToPlace := mines; minesLeftToPlace > 0 && len(freeCells) > 0; minesLeftToPlace-- {
+		index := random.Intn(len(freeCells))
+		cellAt(newBoard, freeCells[index]).HasMine = true
+		freeCells[index] = freeCells[len(freeCells)-1]
+		freeCells = freeCells[:len(freeCells)-1]
+	}
+	return newBoard
+}
+
+// solver plays a board using only the information a player would have
+type solver struct {
+	board    [][][]domain.Cell
+	grid     grid
+	mines    int
+	revealed map[position]bool
+	flagged  map[position]bool
+	safeLeft int
+	work     *int
+}
+
+// isSolvableWithoutGuessing tells if starting from the given cell the whole board can be
+// cleared applying only logical deductions. Every cell visited is taken from the work left,
+// if any, and the solver gives up once there is none
+func isSolvableWithoutGuessing(board [][][]domain.Cell, boardGrid grid, mines int, p position, work *int) bool {
+
+	if cellAt(board, p).HasMine {
+		return false
+	}
+
+	s := &solver{
+		board:    board,
+		grid:     boardGrid,
+		mines:    mines,
+		revealed: make(map[position]bool),
+		flagged:  make(map[position]bool),
+		safeLeft: len(boardGrid.positions()) - mines,
+		work:     work,
+	}
+
+	s.reveal(p)
+	for s.safeLeft > 0 {
+		if s.work != nil && *s.work <= 0 {
+			return false
+		}
+		if !s.applySingleCellRules() && !s.applySubsetRules() && !s.applyMinesCountRule() {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *solver) reveal(p position) {
+
+	if s.revealed[p] {
+		return
+	}
+	s.revealed[p] = true
+	s.safeLeft--
+	if cellAt(s.board, p).SourroundedBy == 0 {
+		neighbours := s.grid.neighbours(p)
+		s.visit(len(neighbours))
+		for _, neighbour := range neighbours {
+			s.reveal(neighbour)
+		}
+	}
+}
+
+// visit takes the cells visited from the work left
+func (s *solver) visit(cells int) {
+	if s.work != nil {
+		*s.work -= cells
+	}
+}
+
+// unknownNeighbours returns the hidden and not flagged neighbours of a revealed cell,
+// and how many of its mines are still not flagged
+func (s *solver) unknownNeighbours(p position) ([]position, int) {
+
+	unknown := make([]position, 0, 8)
+	minesLeft := cellAt(s.board, p).SourroundedBy
+	neighbours := s.grid.neighbours(p)
+	s.visit(len(neighbours))
+	for _, neighbour := range neighbours {
+		if s.flagged[neighbour] {
+			minesLeft--
+		} else if !s.revealed[neighbour] {
+			unknown = append(unknown, neighbour)
+		}
+	}
+	return unknown, minesLeft
+}
+
+// resolve reveals the cells, or flags them when they are mines, returning if there was progress
+func (s *solver) resolve(cells []position, areMines bool) bool {
+
+	progress := false
+	for _, cell := range cells {
+		if s.revealed[cell] || s.flagged[cell] {
+			continue
+		}
+		if areMines {
+			s.flagged[cell] = true
+		} else {
+			s.reveal(cell)
+		}
+		progress = true
+	}
+	return progress
+}
+
+// applySingleCellRules solves the neighbours of the numbers whose mines are all flagged,
+// or whose hidden neighbours must all be mines
+func (s *solver) applySingleCellRules() bool {
+
+	progress := false
+	s.visit(len(s.grid.positions()))
+	for _, cell := range s.grid.positions() {
+		if !s.revealed[cell] {
+			continue
+		}
+		unknown, minesLeft := s.unknownNeighbours(cell)
+		if len(unknown) == 0 {
+			continue
+		}
+		if minesLeft == 0 {
+			progress = s.resolve(unknown, false) || progress
+		} else if minesLeft == len(unknown) {
+			progress = s.resolve(unknown, true) || progress
+		}
+	}
+	return progress
+}
+
+// applySubsetRules compares numbers sharing hidden neighbours: when the hidden neighbours of one
+// are contained in the hidden neighbours of the other, the difference holds the difference of their mines
+func (s *solver) applySubsetRules() bool {
+
+	s.visit(len(s.grid.positions()))
+	for _, cell := range s.grid.positions() {
+		if !s.revealed[cell] {
+			continue
+		}
+		unknown, minesLeft := s.unknownNeighbours(cell)
+		if len(unknown) == 0 {
+			continue
+		}
+		for _, other := range s.numbersSharingCells(cell, unknown) {
+			otherUnknown, otherMinesLeft := s.unknownNeighbours(other)
+			difference, isSubset := cellsDifference(otherUnknown, unknown)
+			if !isSubset || len(difference) == 0 {
+				continue
+			}
+			minesInDifference := otherMinesLeft - minesLeft
+			if minesInDifference == 0 {
+				return s.resolve(difference, false)
+			}
+			if minesInDifference == len(difference) {
+				return s.resolve(difference, true)
+			}
+		}
+	}
+	return false
+}
+
+// numbersSharingCells returns the other revealed cells that are next to any of the given cells
+func (s *solver) numbersSharingCells(p position, cells []position) []position {
+
+	seen := map[position]bool{p: true}
+	numbers := make([]position, 0)
+	for _, cell := range cells {
+		neighbours := s.grid.neighbours(cell)
+		s.visit(len(neighbours))
+		for _, neighbour := range neighbours {
+			if !seen[neighbour] && s.revealed[neighbour] {
+				seen[neighbour] = true
+				numbers = append(numbers, neighbour)
+			}
+		}
+	}
+	return numbers
+}
+
+// applyMinesCountRule uses the total of mines: once every mine is flagged the remaining cells
+// are safe, and if there are as many hidden cells as mines left all of them are mines
+func (s *solver) applyMinesCountRule() bool {
+
+	minesLeft := s.mines
+	unknown := make([]position, 0)
+	s.visit(len(s.grid.positions()))
+	for _, cell := range s.grid.positions() {
+		if s.flagged[cell] {
+			minesLeft--
+		} else if !s.revealed[cell] {
+			unknown = append(unknown, cell)
+		}
+	}
+	if minesLeft == 0 {
+		return s.resolve(unknown, false)
+	}
+	if minesLeft == len(unknown) {
+		return s.resolve(unknown, true)
+	}
+	return false
+}
+
+// cellsDifference returns the cells of set that are not in subset, and false if subset
+// has cells that are not in set
+func cellsDifference(set []position, subset []position) ([]position, bool) {
+
+	inSet := make(map[position]bool, len(set))
+	for _, cell := range set {
+		inSet[cell] = true
+	}
+	for _, cell := range subset {
+		if !inSet[cell] {
+			return nil, false
+		}
+		delete(inSet, cell)
+	}
+
+	difference := make([]position, 0, len(inSet))
+	for _, cell := range set {
+		if inSet[cell] {
+			difference = append(difference, cell)
+		}
+	}
+	return difference, true
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"github.com/mercadolibre/minesweeper/constants"
+	"github.com/mercadolibre/minesweeper/domain"
+)
+
+// GetUserStats sums up the games of a user by status, the games in progress count
+// towards the games played but not towards any result
+func (gs *GameService) GetUserStats(userID string) (*domain.UserStats, error) {
+
+	userGame, err := gs.GetGamesByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if userGame == nil {
+		return nil, nil
+	}
+
+	now := gs.Now()
+	stats := &domain.UserStats{UserID: userID}
+	for _, game := range userGame.Games {
+		stats.GamesPlayed++
+		elapsed := int64(game.Elapsed(now).Seconds())
+		stats.TotalPlayedSeconds += elapsed
+
+		switch game.Status {
+		case constants.GameResultWon:
+			stats.Won++
+			if stats.BestTimeSeconds == nil || elapsed < *stats.BestTimeSeconds {
+				best := elapsed
+				stats.BestTimeSeconds = &best
+			}
+		case constants.GameStatusLost:
+			stats.Lost++
+		case constants.GameStatusAbandoned:
+			stats.Abandoned++
+		case constants.GameStatusTimedOut:
+			stats.TimedOut++
+		default:
+			stats.InProgress++
+		}
+	}
+	return stats, nil
+}